@@ -1,19 +1,26 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"html/template"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"PromAI/pkg/config"
 	"PromAI/pkg/metrics"
+	"PromAI/pkg/notify"
 	"PromAI/pkg/prometheus"
 	"PromAI/pkg/report"
+	"PromAI/pkg/scheduler"
 	"PromAI/pkg/status"
+	"PromAI/templates"
 
 	"gopkg.in/yaml.v2"
 )
@@ -39,19 +46,58 @@ func loadConfig(path string) (*config.Config, error) {
 	return &config, nil // 返回配置结构体
 }
 
-// setup 初始化应用程序
-func setup(configPath string) (*prometheus.Client, *config.Config, error) {
+// setup 初始化应用程序，为每个数据源创建 Prometheus 客户端
+func setup(configPath string) (map[string]metrics.PrometheusAPI, *config.Config, error) {
 	config, err := loadConfig(configPath)
 	if err != nil {
 		return nil, nil, fmt.Errorf("loading config: %w", err)
 	}
 
-	client, err := prometheus.NewClient(config.PrometheusURL)
-	if err != nil {
-		return nil, nil, fmt.Errorf("initializing Prometheus client: %w", err)
+	if err := metrics.ValidateConfig(config); err != nil {
+		return nil, nil, fmt.Errorf("validating thresholds: %w", err)
+	}
+
+	if _, err := config.Retention.MaxTotalBytes(); err != nil {
+		return nil, nil, fmt.Errorf("validating retention: %w", err)
+	}
+
+	for _, schedule := range config.Schedules {
+		if schedule.Format == report.FormatPDF && config.PDF.FontFile == "" {
+			return nil, nil, fmt.Errorf("schedule %s generates PDF reports but pdf.font_file is not configured", schedule.Name)
+		}
+	}
+
+	if dir := config.Templates.Dir; dir != "" {
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return nil, nil, fmt.Errorf("templates.dir %q is not a directory", dir)
+		}
+		templates.Dir = dir
+		log.Printf("使用模板目录: %s，其中的文件覆盖内置模板", dir)
+	}
+
+	// 使用示例数据校验报告模板，模板有误时启动失败
+	if err := report.ValidateTemplates(); err != nil {
+		return nil, nil, fmt.Errorf("validating report templates: %w", err)
+	}
+	if err := report.RegisterProfiles(config.Templates.Profiles); err != nil {
+		return nil, nil, fmt.Errorf("validating report templates: %w", err)
+	}
+
+	if err := config.ValidateDatasources(); err != nil {
+		return nil, nil, fmt.Errorf("validating datasources: %w", err)
+	}
+
+	datasources := config.AllDatasources()
+	clients := make(map[string]metrics.PrometheusAPI, len(datasources))
+	for _, ds := range datasources {
+		client, err := prometheus.NewClient(ds.URL, ds.HTTPConfig)
+		if err != nil {
+			return nil, nil, fmt.Errorf("initializing Prometheus client %s: %w", ds.Name, err)
+		}
+		clients[ds.Name] = client.API
 	}
 
-	return client, config, nil
+	return clients, config, nil
 }
 
 func main() {
@@ -59,50 +105,123 @@ func main() {
 	port := flag.String("port", "8091", "Port to run the HTTP server on")
 	flag.Parse()
 
-	client, config, err := setup(*configPath)
+	clients, config, err := setup(*configPath)
 	if err != nil {
 		log.Fatalf("Error setting up: %v", err)
 	}
 
-	collector := metrics.NewCollector(client.API, config)
+	collector := metrics.NewCollector(clients, config)
+
+	statusCache, err := status.NewCache(config.Status.CacheFile)
+	if err != nil {
+		log.Fatalf("Error loading status cache: %v", err)
+	}
+
+	// 启动历史报告清理
+	janitor := report.NewJanitor(config.Retention)
+	janitor.Start(context.Background())
+
+	// 初始化通知
+	dispatcher, err := notify.NewDispatcher(config.Notify)
+	if err != nil {
+		log.Fatalf("Error setting up notifications: %v", err)
+	}
+
+	// PDF 渲染器需要配置的字体
+	report.RegisterRenderer(report.NewPDFRenderer(config.PDF))
+
+	inspector := &inspector{
+		collector:  collector,
+		janitor:    janitor,
+		dispatcher: dispatcher,
+	}
+
+	// 启动定时巡检
+	sched, err := scheduler.New(config.Schedules, inspector.run, report.SupportedFormat)
+	if err != nil {
+		log.Fatalf("Error setting up schedules: %v", err)
+	}
+	sched.Start()
 
 	// 设置路由处理器
-	setupRoutes(collector, config)
+	setupRoutes(collector, config, statusCache, inspector, sched)
 
 	// 启动服务器
 	log.Printf("Starting server on port: %s with config: %s", *port, *configPath)
-	log.Printf("Prometheus URL: %s", config.PrometheusURL)
+	for _, ds := range config.AllDatasources() {
+		log.Printf("Prometheus 数据源 [%s]: %s", ds.Name, ds.URL)
+	}
 	log.Printf("获取报告地址: http://localhost:%s/getreport", *port)
 	log.Printf("健康看板地址: http://localhost:%s/status", *port)
+	log.Printf("报告数据接口: http://localhost:%s/api/v1/report", *port)
+	log.Printf("看板数据接口: http://localhost:%s/api/v1/status", *port)
+	log.Printf("历史报告地址: http://localhost:%s/reports/", *port)
+	log.Printf("定时任务接口: http://localhost:%s/api/v1/schedules", *port)
 	if err := http.ListenAndServe(":"+*port, nil); err != nil {
 		log.Fatalf("Error starting HTTP server: %v", err)
 	}
 }
 
 // setupRoutes 设置 HTTP 路由
-func setupRoutes(collector *metrics.Collector, config *config.Config) {
+func setupRoutes(collector *metrics.Collector, config *config.Config, statusCache *status.Cache, inspector *inspector, sched *scheduler.Scheduler) {
 	// 设置报告生成路由
-	http.HandleFunc("/getreport", makeReportHandler(collector))
+	http.HandleFunc("/getreport", makeReportHandler(inspector))
 
 	// 设置静态文件服务
-	http.Handle("/reports/", http.StripPrefix("/reports/", http.FileServer(http.Dir("reports"))))
+	http.Handle("/reports/", http.StripPrefix("/reports/", http.FileServer(http.Dir(report.ReportDir))))
+
+	// 设置历史报告目录路由
+	http.HandleFunc("GET /reports/{$}", makeHistoryHandler())
+	http.HandleFunc("GET /reports/latest", makeLatestReportHandler())
 
 	// 设置状态页面路由
-	http.HandleFunc("/status", makeStatusHandler(collector.Client, config))
+	http.HandleFunc("/status", makeStatusHandler(collector, config, statusCache))
+
+	// 设置 JSON 接口路由
+	http.HandleFunc("GET /api/v1/report", makeReportAPIHandler(collector))
+	http.HandleFunc("GET /api/v1/status", makeStatusAPIHandler(collector, config, statusCache))
+	http.HandleFunc("GET /api/v1/reports", makeReportListAPIHandler())
+	http.HandleFunc("GET /api/v1/reports/{id}", makeReportGetAPIHandler())
+	http.HandleFunc("DELETE /api/v1/reports/{id}", makeReportDeleteAPIHandler())
+	http.HandleFunc("GET /api/v1/schedules", makeScheduleAPIHandler(sched))
+}
 
+// inspector 执行一次完整的巡检：采集指标、生成报告、清理历史报告并发送通知，/getreport 和定时任务共用
+type inspector struct {
+	collector  *metrics.Collector
+	janitor    *report.Janitor
+	dispatcher *notify.Dispatcher
+}
+
+// run 执行一次巡检并生成指定格式的报告，返回报告文件路径
+func (i *inspector) run(ctx context.Context, format string) (string, error) {
+	data, err := i.collector.CollectMetrics(ctx)
+	if err != nil {
+		return "", fmt.Errorf("collecting metrics: %w", err)
+	}
+
+	reportFilePath, err := report.GenerateReport(*data, format)
+	if err != nil {
+		return "", fmt.Errorf("generating report: %w", err)
+	}
+	i.janitor.Trigger()
+
+	// 通知在后台发送，不阻塞报告返回
+	go i.dispatcher.Dispatch(context.Background(), data, reportFilePath)
+
+	return reportFilePath, nil
 }
 
 // makeReportHandler 创建报告处理器
-func makeReportHandler(collector *metrics.Collector) http.HandlerFunc {
+func makeReportHandler(inspector *inspector) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		data, err := collector.CollectMetrics()
-		if err != nil {
-			http.Error(w, "Failed to collect metrics", http.StatusInternalServerError)
-			log.Printf("Error collecting metrics: %v", err)
+		format := r.URL.Query().Get("format")
+		if !report.SupportedFormat(format) {
+			http.Error(w, fmt.Sprintf("Unsupported report format, supported: %s", strings.Join(report.Formats(), ", ")), http.StatusBadRequest)
 			return
 		}
 
-		reportFilePath, err := report.GenerateReport(*data)
+		reportFilePath, err := inspector.run(r.Context(), format)
 		if err != nil {
 			http.Error(w, "Failed to generate report", http.StatusInternalServerError)
 			log.Printf("Error generating report: %v", err)
@@ -113,10 +232,165 @@ func makeReportHandler(collector *metrics.Collector) http.HandlerFunc {
 	}
 }
 
+// makeReportAPIHandler 创建以 JSON 返回巡检数据的处理器
+func makeReportAPIHandler(collector *metrics.Collector) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := collector.CollectMetrics(r.Context())
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to collect metrics")
+			log.Printf("Error collecting metrics: %v", err)
+			return
+		}
+
+		if err := report.PrepareReport(data); err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to prepare report")
+			log.Printf("Error preparing report: %v", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+// makeStatusAPIHandler 创建以 JSON 返回健康看板数据的处理器
+func makeStatusAPIHandler(collector *metrics.Collector, config *config.Config, statusCache *status.Cache) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data, err := status.CollectMetricStatus(r.Context(), collector, config, statusCache)
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to collect status data")
+			log.Printf("Error collecting status data: %v", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, data)
+	}
+}
+
+// makeReportListAPIHandler 创建列出历史报告的处理器
+func makeReportListAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reports, err := report.ListReports()
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to list reports")
+			log.Printf("Error listing reports: %v", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, reports)
+	}
+}
+
+// makeReportGetAPIHandler 创建获取单个报告元数据的处理器，id 为 latest 时返回最新报告
+func makeReportGetAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meta, err := report.GetReport(r.PathValue("id"))
+		if errors.Is(err, report.ErrReportNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Report not found")
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to get report")
+			log.Printf("Error getting report: %v", err)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, meta)
+	}
+}
+
+// makeReportDeleteAPIHandler 创建删除报告的处理器
+func makeReportDeleteAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.PathValue("id")
+		err := report.DeleteReport(id)
+		if errors.Is(err, report.ErrReportNotFound) {
+			writeJSONError(w, http.StatusNotFound, "Report not found")
+			return
+		}
+		if err != nil {
+			writeJSONError(w, http.StatusInternalServerError, "Failed to delete report")
+			log.Printf("Error deleting report: %v", err)
+			return
+		}
+
+		log.Printf("已删除报告: %s", id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// makeLatestReportHandler 创建跳转到最新报告的处理器
+func makeLatestReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meta, err := report.GetReport(report.LatestReportID)
+		if errors.Is(err, report.ErrReportNotFound) {
+			http.Error(w, "No report generated yet", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Failed to get latest report", http.StatusInternalServerError)
+			log.Printf("Error getting latest report: %v", err)
+			return
+		}
+
+		http.Redirect(w, r, meta.URL, http.StatusFound)
+	}
+}
+
+// makeHistoryHandler 创建历史报告目录页面处理器
+func makeHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reports, err := report.ListReports()
+		if err != nil {
+			http.Error(w, "Failed to list reports", http.StatusInternalServerError)
+			log.Printf("Error listing reports: %v", err)
+			return
+		}
+
+		funcMap := template.FuncMap{
+			"date": report.FormatDate,
+			"size": func(size int64) string { return report.FormatBytes(float64(size)) },
+		}
+
+		tmpl, err := templates.Parse(template.New("history.html").Funcs(funcMap), "history.html")
+		if err != nil {
+			http.Error(w, "Failed to parse template", http.StatusInternalServerError)
+			log.Printf("Error parsing template: %v", err)
+			return
+		}
+
+		if err := tmpl.Execute(w, reports); err != nil {
+			http.Error(w, "Failed to render template", http.StatusInternalServerError)
+			log.Printf("Error rendering template: %v", err)
+			return
+		}
+	}
+}
+
+// makeScheduleAPIHandler 创建返回定时任务状态的处理器
+func makeScheduleAPIHandler(sched *scheduler.Scheduler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, sched.Status())
+	}
+}
+
+// writeJSON 以 JSON 格式写入响应
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("Error encoding JSON response: %v", err)
+	}
+}
+
+// writeJSONError 以 JSON 格式写入错误响应
+func writeJSONError(w http.ResponseWriter, code int, message string) {
+	writeJSON(w, code, map[string]string{"error": message})
+}
+
 // makeStatusHandler 创建状态页面处理器
-func makeStatusHandler(client metrics.PrometheusAPI, config *config.Config) http.HandlerFunc {
+func makeStatusHandler(collector *metrics.Collector, config *config.Config, statusCache *status.Cache) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		data, err := status.CollectMetricStatus(client, config)
+		data, err := status.CollectMetricStatus(r.Context(), collector, config, statusCache)
 		if err != nil {
 			http.Error(w, "Failed to collect status data", http.StatusInternalServerError)
 			log.Printf("Error collecting status data: %v", err)
@@ -125,14 +399,11 @@ func makeStatusHandler(client metrics.PrometheusAPI, config *config.Config) http
 
 		// 创建模板函数映射
 		funcMap := template.FuncMap{
-			"now": time.Now,
-			"date": func(format string, t time.Time) string {
-				return t.Format(format)
-			},
+			"now":  time.Now,
+			"date": report.FormatDate,
 		}
 
-		tmpl := template.New("status.html").Funcs(funcMap)
-		tmpl, err = tmpl.ParseFiles("templates/status.html")
+		tmpl, err := templates.Parse(template.New("status.html").Funcs(funcMap), "status.html")
 		if err != nil {
 			http.Error(w, "Failed to parse template", http.StatusInternalServerError)
 			log.Printf("Error parsing template: %v", err)
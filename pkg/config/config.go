@@ -1,21 +1,462 @@
 package config
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultTrendRange 趋势图默认时间范围
+	DefaultTrendRange = 6 * time.Hour
+	// DefaultTrendStep 趋势图默认采样间隔
+	DefaultTrendStep = 5 * time.Minute
+
+	// DefaultCollectConcurrency 默认并发查询数
+	DefaultCollectConcurrency = 5
+	// DefaultQueryTimeout 单个查询默认超时时间
+	DefaultQueryTimeout = 30 * time.Second
+	// DefaultCollectTimeout 整体采集默认超时时间
+	DefaultCollectTimeout = 2 * time.Minute
+
+	// DefaultDatasourceName prometheus_url 对应的数据源名称
+	DefaultDatasourceName = "default"
+
+	// DefaultStatusDays 健康看板默认显示天数
+	DefaultStatusDays = 7
+	// DefaultStatusConcurrency 健康看板默认并发查询数
+	DefaultStatusConcurrency = 10
+
+	// DefaultNotifyTopN 通知摘要中默认列出的异常记录数
+	DefaultNotifyTopN = 10
+	// DefaultNotifyTimeout 单次发送通知的默认超时时间
+	DefaultNotifyTimeout = 10 * time.Second
+	// DefaultNotifyRetries 发送通知失败时默认重试次数
+	DefaultNotifyRetries = 3
+	// DefaultNotifyRetryInterval 首次重试的默认间隔，之后每次翻倍
+	DefaultNotifyRetryInterval = 5 * time.Second
+)
+
 type Config struct {
-	PrometheusURL string       `yaml:"prometheus_url"`
-	MetricTypes   []MetricType `yaml:"metric_types"`
+	PrometheusURL string          `yaml:"prometheus_url"`
+	HTTPConfig    HTTPConfig      `yaml:"http_config"`
+	Datasources   []Datasource    `yaml:"datasources"`
+	Fleet         FleetConfig     `yaml:"fleet"`
+	Trend         TrendConfig     `yaml:"trend"`
+	Collect       CollectConfig   `yaml:"collect"`
+	Status        StatusConfig    `yaml:"status"`
+	Retention     RetentionConfig `yaml:"retention"`
+	Schedules     []Schedule      `yaml:"schedules"`
+	Notify        NotifyConfig    `yaml:"notify"`
+	PDF           PDFConfig       `yaml:"pdf"`
+	Templates     TemplatesConfig `yaml:"templates"`
+	MetricTypes   []MetricType    `yaml:"metric_types"`
+}
+
+// Datasource 命名的 Prometheus 数据源
+type Datasource struct {
+	Name       string     `yaml:"name"`
+	URL        string     `yaml:"url"`
+	HTTPConfig HTTPConfig `yaml:"http_config"`
+}
+
+// AllDatasources 返回全部数据源，配置了 prometheus_url 时将其作为名为 default 的数据源
+func (c *Config) AllDatasources() []Datasource {
+	datasources := make([]Datasource, 0, len(c.Datasources)+1)
+	if c.PrometheusURL != "" {
+		datasources = append(datasources, Datasource{
+			Name:       DefaultDatasourceName,
+			URL:        c.PrometheusURL,
+			HTTPConfig: c.HTTPConfig,
+		})
+	}
+	return append(datasources, c.Datasources...)
+}
+
+// ValidateDatasources 检查数据源配置，以及指标、指标类型和 fleet.clusters 引用的数据源是否存在
+func (c *Config) ValidateDatasources() error {
+	datasources := c.AllDatasources()
+	if len(datasources) == 0 {
+		return fmt.Errorf("no prometheus_url or datasources configured")
+	}
+
+	names := make(map[string]bool, len(datasources))
+	for _, ds := range c.Datasources {
+		if ds.Name == DefaultDatasourceName && c.PrometheusURL != "" {
+			return fmt.Errorf("datasource name %q conflicts with prometheus_url, rename the datasource or remove prometheus_url", ds.Name)
+		}
+	}
+	for _, ds := range datasources {
+		if ds.Name == "" || ds.URL == "" {
+			return fmt.Errorf("datasource requires both name and url: %q", ds.Name)
+		}
+		if names[ds.Name] {
+			return fmt.Errorf("duplicate datasource name: %s", ds.Name)
+		}
+		names[ds.Name] = true
+	}
+
+	for _, metricType := range c.MetricTypes {
+		if metricType.Datasource != "" && !names[metricType.Datasource] {
+			return fmt.Errorf("metric type %s: unknown datasource %q", metricType.Type, metricType.Datasource)
+		}
+		for _, metric := range metricType.Metrics {
+			if metric.Datasource != "" && !names[metric.Datasource] {
+				return fmt.Errorf("metric %s/%s: unknown datasource %q", metricType.Type, metric.Name, metric.Datasource)
+			}
+		}
+	}
+	for _, cluster := range c.Fleet.Clusters {
+		if !names[cluster] {
+			return fmt.Errorf("fleet.clusters: unknown datasource %q", cluster)
+		}
+	}
+	return nil
+}
+
+// DefaultDatasource 返回未指定数据源的指标使用的数据源名称
+func (c *Config) DefaultDatasource() string {
+	if datasources := c.AllDatasources(); len(datasources) > 0 {
+		return datasources[0].Name
+	}
+	return ""
+}
+
+// FleetConfig 多集群巡检配置
+// 开启后每个指标都会在所有集群上执行，指标和类型上的 datasource 配置将被忽略
+type FleetConfig struct {
+	Enabled  bool     `yaml:"enabled"`
+	Clusters []string `yaml:"clusters"` // 参与巡检的数据源名称，留空表示全部数据源
+}
+
+// FleetClusters 返回多集群巡检的数据源名称列表
+func (c *Config) FleetClusters() []string {
+	if len(c.Fleet.Clusters) > 0 {
+		return c.Fleet.Clusters
+	}
+	datasources := c.AllDatasources()
+	names := make([]string, 0, len(datasources))
+	for _, ds := range datasources {
+		names = append(names, ds.Name)
+	}
+	return names
+}
+
+// HTTPConfig 访问 Prometheus 的认证、TLS 和代理配置
+type HTTPConfig struct {
+	BasicAuth       *BasicAuth        `yaml:"basic_auth"`
+	BearerToken     string            `yaml:"bearer_token"`
+	BearerTokenFile string            `yaml:"bearer_token_file"` // 每次请求时重新读取，支持令牌轮换
+	TLSConfig       TLSConfig         `yaml:"tls_config"`
+	ProxyURL        string            `yaml:"proxy_url"`
+	Headers         map[string]string `yaml:"headers"` // 附加的 HTTP 请求头
+}
+
+// BasicAuth HTTP 基本认证配置
+type BasicAuth struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	PasswordFile string `yaml:"password_file"` // 每次请求时重新读取
+}
+
+// TLSConfig TLS 配置
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"` // 客户端证书，每次握手时重新加载
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// TrendConfig 趋势图配置
+type TrendConfig struct {
+	Enabled bool          `yaml:"enabled"` // 未配置 trend_query 的指标是否使用 query 自动生成趋势图
+	Range   time.Duration `yaml:"range"`   // 趋势图时间范围，例如 6h
+	Step    time.Duration `yaml:"step"`    // 采样间隔，例如 5m
+}
+
+// Window 返回趋势图的时间范围和采样间隔，未配置时使用默认值
+func (t TrendConfig) Window() (time.Duration, time.Duration) {
+	r, step := t.Range, t.Step
+	if r <= 0 {
+		r = DefaultTrendRange
+	}
+	if step <= 0 {
+		step = DefaultTrendStep
+	}
+	return r, step
+}
+
+// CollectConfig 指标采集配置
+type CollectConfig struct {
+	Concurrency  int           `yaml:"concurrency"`   // 并发查询数
+	QueryTimeout time.Duration `yaml:"query_timeout"` // 单个查询超时时间
+	Timeout      time.Duration `yaml:"timeout"`       // 整体采集超时时间
+}
+
+// Limits 返回并发数、单个查询超时和整体超时，未配置时使用默认值
+func (c CollectConfig) Limits() (int, time.Duration, time.Duration) {
+	concurrency, queryTimeout, timeout := c.Concurrency, c.QueryTimeout, c.Timeout
+	if concurrency <= 0 {
+		concurrency = DefaultCollectConcurrency
+	}
+	if queryTimeout <= 0 {
+		queryTimeout = DefaultQueryTimeout
+	}
+	if timeout <= 0 {
+		timeout = DefaultCollectTimeout
+	}
+	return concurrency, queryTimeout, timeout
+}
+
+// StatusConfig 健康看板配置
+type StatusConfig struct {
+	Days        int    `yaml:"days"`        // 显示最近多少天的状态
+	Concurrency int    `yaml:"concurrency"` // 并发查询数
+	CacheFile   string `yaml:"cache_file"`  // 历史状态缓存文件，留空则只缓存在内存中
+}
+
+// Limits 返回显示天数和并发查询数，未配置时使用默认值
+func (s StatusConfig) Limits() (int, int) {
+	days, concurrency := s.Days, s.Concurrency
+	if days <= 0 {
+		days = DefaultStatusDays
+	}
+	if concurrency <= 0 {
+		concurrency = DefaultStatusConcurrency
+	}
+	return days, concurrency
+}
+
+// RetentionConfig 历史报告保留策略，全部留空表示永久保留
+type RetentionConfig struct {
+	MaxAge        time.Duration `yaml:"max_age"`         // 报告最长保留时间，例如 720h
+	MaxCount      int           `yaml:"max_count"`       // 最多保留的报告数量
+	MaxTotalSize  string        `yaml:"max_total_size"`  // 报告总大小上限，例如 2GB
+	KeepAllWithin time.Duration `yaml:"keep_all_within"` // 该时间范围内的报告全部保留，更早的报告每天只保留最后一份
+}
+
+// Enabled 是否配置了任何保留策略
+func (r RetentionConfig) Enabled() bool {
+	return r.MaxAge > 0 || r.MaxCount > 0 || r.MaxTotalSize != "" || r.KeepAllWithin > 0
+}
+
+// MaxTotalBytes 解析 max_total_size，未配置时返回 0
+func (r RetentionConfig) MaxTotalBytes() (int64, error) {
+	if r.MaxTotalSize == "" {
+		return 0, nil
+	}
+	return ParseSize(r.MaxTotalSize)
+}
+
+// sizeUnits 大小单位，按 1024 进制换算
+var sizeUnits = []struct {
+	suffix string
+	factor int64
+}{
+	{"TB", 1 << 40},
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"T", 1 << 40},
+	{"G", 1 << 30},
+	{"M", 1 << 20},
+	{"K", 1 << 10},
+	{"B", 1},
+}
+
+// ParseSize 解析带单位的大小，例如 500MB、2GB、1.5G，不带单位时按字节计算
+func ParseSize(s string) (int64, error) {
+	value := strings.ToUpper(strings.TrimSpace(s))
+	factor := int64(1)
+	for _, unit := range sizeUnits {
+		if strings.HasSuffix(value, unit.suffix) {
+			value, factor = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix)), unit.factor
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(n * float64(factor)), nil
+}
+
+// Schedule 定时生成报告的任务
+type Schedule struct {
+	Name   string        `yaml:"name"`
+	Cron   string        `yaml:"cron"`   // 标准 5 位 cron 表达式，也支持 @daily、@every 1h 等写法
+	Jitter time.Duration `yaml:"jitter"` // 每次执行前随机延迟的上限，避免多个实例同时查询
+	Format string        `yaml:"format"` // 报告格式：html、pdf、xlsx、csv、md、txt 或 json，默认 html
+}
+
+// PDFConfig PDF 报告配置
+type PDFConfig struct {
+	FontFile     string `yaml:"font_file"`      // 支持中文的 TrueType 字体文件（.ttf），例如 NotoSansSC-Regular.ttf，生成 PDF 时必须配置
+	BoldFontFile string `yaml:"bold_font_file"` // 粗体字体文件，留空时使用 font_file
+}
+
+// TemplatesConfig 模板配置，模板和 Chart.js 默认编译在程序中
+type TemplatesConfig struct {
+	Dir      string          `yaml:"dir"`      // 覆盖内置模板的目录，例如 templates，目录中存在的同名文件优先使用
+	Profiles []ReportProfile `yaml:"profiles"` // 使用自定义模板的报告
+}
+
+// ReportProfile 使用自定义模板生成的报告，名称作为 /getreport 和定时任务的 format
+type ReportProfile struct {
+	Name      string `yaml:"name"`
+	Template  string `yaml:"template"`  // 模板文件，相对路径先在 templates.dir 中查找，再使用内置模板
+	Type      string `yaml:"type"`      // html 或 text，html 会转义输出内容，默认 .html/.htm 模板为 html，其余为 text
+	Extension string `yaml:"extension"` // 报告文件扩展名，默认与模板文件相同
+}
+
+// NotifyConfig 报告生成后的通知配置
+type NotifyConfig struct {
+	ExternalURL string          `yaml:"external_url"` // 报告链接的访问地址前缀，例如 http://promai.example.com
+	TopN        int             `yaml:"top_n"`        // 摘要中列出的异常记录数，默认 10
+	Webhooks    []WebhookConfig `yaml:"webhooks"`
+	DingTalk    []RobotConfig   `yaml:"dingtalk"` // 钉钉群机器人
+	WeCom       []RobotConfig   `yaml:"wecom"`    // 企业微信群机器人
+	Feishu      []RobotConfig   `yaml:"feishu"`   // 飞书群机器人
+	Email       []EmailConfig   `yaml:"email"`    // SMTP 邮件
+	Slack       []CardConfig    `yaml:"slack"`    // Slack Incoming Webhook
+	Teams       []CardConfig    `yaml:"teams"`    // Microsoft Teams Incoming Webhook
+}
+
+// Limit 返回摘要中列出的异常记录数，未配置时使用默认值
+func (n NotifyConfig) Limit() int {
+	if n.TopN <= 0 {
+		return DefaultNotifyTopN
+	}
+	return n.TopN
+}
+
+// NotifierConfig 各类通知共用的配置
+type NotifierConfig struct {
+	Name          string        `yaml:"name"`
+	Filter        string        `yaml:"filter"`         // expr 表达式，结果为 true 时才发送，例如 CriticalCount > 0，留空表示总是发送
+	Timeout       time.Duration `yaml:"timeout"`        // 单次发送超时时间
+	Retries       int           `yaml:"retries"`        // 失败重试次数，设置为 -1 表示不重试
+	RetryInterval time.Duration `yaml:"retry_interval"` // 首次重试间隔，之后每次翻倍
+}
+
+// RetryPolicy 返回单次发送超时、重试次数和首次重试间隔，未配置时使用默认值
+func (n NotifierConfig) RetryPolicy() (time.Duration, int, time.Duration) {
+	timeout, retries, interval := n.Timeout, n.Retries, n.RetryInterval
+	if timeout <= 0 {
+		timeout = DefaultNotifyTimeout
+	}
+	if retries == 0 {
+		retries = DefaultNotifyRetries
+	} else if retries < 0 {
+		retries = 0
+	}
+	if interval <= 0 {
+		interval = DefaultNotifyRetryInterval
+	}
+	return timeout, retries, interval
+}
+
+// WebhookConfig 通用 JSON Webhook 通知配置
+type WebhookConfig struct {
+	NotifierConfig `yaml:",inline"`
+	URL            string            `yaml:"url"`
+	Method         string            `yaml:"method"`   // 默认 POST
+	Headers        map[string]string `yaml:"headers"`  // 附加的 HTTP 请求头
+	Template       string            `yaml:"template"` // 请求体模板（text/template），留空时发送 JSON 格式的摘要
+}
+
+// RobotConfig 钉钉、企业微信和飞书群机器人通知配置
+type RobotConfig struct {
+	NotifierConfig `yaml:",inline"`
+	URL            string `yaml:"url"`      // 机器人 Webhook 地址
+	Secret         string `yaml:"secret"`   // 加签密钥，仅钉钉和飞书支持
+	Template       string `yaml:"template"` // markdown 消息模板（text/template），留空使用内置模板
+}
+
+// CardConfig Slack 和 Microsoft Teams 通知配置，消息内容由摘要生成
+type CardConfig struct {
+	NotifierConfig `yaml:",inline"`
+	URL            string `yaml:"url"` // Incoming Webhook 地址
+}
+
+// EmailConfig SMTP 邮件通知配置
+type EmailConfig struct {
+	NotifierConfig     `yaml:",inline"`
+	Host               string              `yaml:"host"`
+	Port               int                 `yaml:"port"` // 默认 587，465 端口默认使用 TLS 连接
+	Username           string              `yaml:"username"`
+	Password           string              `yaml:"password"`
+	PasswordFile       string              `yaml:"password_file"` // 每次发送时重新读取
+	From               string              `yaml:"from"`
+	To                 []string            `yaml:"to"`              // 接收完整报告的收件人
+	Cc                 []string            `yaml:"cc"`              // 抄送，与 to 一起接收完整报告
+	TypeRecipients     map[string][]string `yaml:"type_recipients"` // 按指标类型订阅的收件人，邮件正文只包含订阅的指标类型
+	Subject            string              `yaml:"subject"`         // 邮件主题模板（text/template），留空使用默认主题
+	TLSMode            string              `yaml:"tls_mode"`        // starttls、tls 或 none，默认 465 端口使用 tls，其他端口在服务器支持时使用 starttls
+	InsecureSkipVerify bool                `yaml:"insecure_skip_verify"`
 }
 
 type MetricType struct {
-	Type    string         `yaml:"type"`
-	Metrics []MetricConfig `yaml:"metrics"`
+	Type       string         `yaml:"type"`
+	Datasource string         `yaml:"datasource"` // 该类型下指标默认使用的数据源
+	Metrics    []MetricConfig `yaml:"metrics"`
+}
+
+// DatasourceFor 返回指标使用的数据源名称，指标未配置时使用类型的配置，均未配置时返回空字符串表示默认数据源
+func (t MetricType) DatasourceFor(metric MetricConfig) string {
+	if metric.Datasource != "" {
+		return metric.Datasource
+	}
+	return t.Datasource
+}
+
+// Band 阈值分段，值落在 [min, max) 区间内时为对应状态，min/max 留空表示不限
+type Band struct {
+	Min    *float64 `yaml:"min"`
+	Max    *float64 `yaml:"max"`
+	Status string   `yaml:"status"` // normal, warning 或 critical
+}
+
+// ThresholdOverride 按标签匹配的阈值覆盖，匹配的序列使用此处配置的阈值，未配置的字段沿用指标的配置
+type ThresholdOverride struct {
+	Name              string   `yaml:"name"`  // 显示名称，留空时使用 match
+	Match             string   `yaml:"match"` // 标签匹配器，例如 mountpoint=~"/data.*", instance!="10.0.0.1:9100"
+	Threshold         *float64 `yaml:"threshold"`
+	ThresholdType     string   `yaml:"threshold_type"`
+	WarningThreshold  *float64 `yaml:"warning_threshold"`
+	CriticalThreshold *float64 `yaml:"critical_threshold"`
+	Min               *float64 `yaml:"min"`
+	Max               *float64 `yaml:"max"`
+	Bands             []Band   `yaml:"bands"`
+	StatusRule        string   `yaml:"status_rule"`
+}
+
+// DisplayName 返回覆盖规则的显示名称
+func (o ThresholdOverride) DisplayName() string {
+	if o.Name != "" {
+		return o.Name
+	}
+	return o.Match
 }
 
 type MetricConfig struct {
-	Name          string            `yaml:"name"`
-	Description   string            `yaml:"description"`
-	Query         string            `yaml:"query"`
-	Threshold     float64           `yaml:"threshold"`
-	Unit          string            `yaml:"unit"`
-	Labels        map[string]string `yaml:"labels"`
-	ThresholdType string            `yaml:"threshold_type"`
+	Name              string              `yaml:"name"`
+	Description       string              `yaml:"description"`
+	Query             string              `yaml:"query"`
+	Datasource        string              `yaml:"datasource"` // 使用的数据源名称，覆盖类型上的配置
+	TrendQuery        string              `yaml:"trend_query"`
+	Threshold         float64             `yaml:"threshold"`
+	WarningThreshold  *float64            `yaml:"warning_threshold"`  // 警告阈值，未配置时按默认系数由严重阈值推算
+	CriticalThreshold *float64            `yaml:"critical_threshold"` // 严重阈值，未配置时使用 threshold
+	Min               *float64            `yaml:"min"`                // between/outside 的下限
+	Max               *float64            `yaml:"max"`                // between/outside 的上限
+	Bands             []Band              `yaml:"bands"`              // bands 类型的分段，按顺序匹配
+	Unit              string              `yaml:"unit"`
+	Labels            map[string]string   `yaml:"labels"`
+	ThresholdType     string              `yaml:"threshold_type"`
+	StatusRule        string              `yaml:"status_rule"` // 状态规则表达式，优先于 threshold_type
+	Overrides         []ThresholdOverride `yaml:"overrides"`   // 按标签匹配的阈值覆盖，按顺序匹配第一个
+	Reduce            string              `yaml:"reduce"`      // 区间向量（matrix）结果的聚合方式: last, max, min, avg，默认 last
 }
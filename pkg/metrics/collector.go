@@ -2,9 +2,11 @@ package metrics
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"log"
+	"sync"
 	"time"
 
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
@@ -16,8 +18,8 @@ import (
 
 // Collector 处理指标收集
 type Collector struct {
-	Client PrometheusAPI
-	config *config.Config
+	Clients map[string]PrometheusAPI // 按名称索引的全部数据源
+	config  *config.Config
 }
 
 type PrometheusAPI interface {
@@ -25,95 +27,317 @@ type PrometheusAPI interface {
 	QueryRange(ctx context.Context, query string, r v1.Range, opts ...v1.Option) (model.Value, v1.Warnings, error)
 }
 
-// NewCollector 创建新的收集器
-func NewCollector(client PrometheusAPI, config *config.Config) *Collector {
+// NewCollector 创建新的收集器，clients 为按数据源名称索引的客户端
+func NewCollector(clients map[string]PrometheusAPI, config *config.Config) *Collector {
 	return &Collector{
-		Client: client,
-		config: config,
+		Clients: clients,
+		config:  config,
 	}
 }
 
+// ResolveDatasource 返回实际使用的数据源名称，名称为空时返回默认数据源
+func (c *Collector) ResolveDatasource(name string) string {
+	if name == "" {
+		return c.config.DefaultDatasource()
+	}
+	return name
+}
+
+// ClientFor 返回指定数据源的客户端，名称为空时返回默认数据源
+func (c *Collector) ClientFor(name string) (PrometheusAPI, error) {
+	name = c.ResolveDatasource(name)
+	client, ok := c.Clients[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown datasource: %q", name)
+	}
+	return client, nil
+}
+
+// collectJob 单个指标的采集任务
+type collectJob struct {
+	group      *report.MetricGroup
+	metric     config.MetricConfig
+	datasource string
+	at         time.Time // 查询时间，同一次采集的所有任务相同
+}
+
+// collectResult 单个指标的采集结果
+type collectResult struct {
+	metrics  []report.MetricData
+	trend    *report.TrendData
+	timeouts []report.TimedOutMetric
+}
+
 // CollectMetrics 收集指标数据
-func (c *Collector) CollectMetrics() (*report.ReportData, error) {
-	ctx := context.Background()
+// 查询按 collect.concurrency 并发执行，每个查询受 collect.query_timeout 限制，
+// 整体受 collect.timeout 和 ctx 限制，超时的指标记录在 ReportData.TimedOutMetrics 中
+func (c *Collector) CollectMetrics(ctx context.Context) (*report.ReportData, error) {
+	concurrency, queryTimeout, timeout := c.config.Collect.Limits()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
 	data := &report.ReportData{
 		Timestamp:    time.Now(),
 		MetricGroups: make(map[string]*report.MetricGroup),
 		ChartData:    make(map[string]template.JS),
+		Fleet:        c.config.Fleet.Enabled,
 	}
 
+	var jobs []collectJob
 	for _, metricType := range c.config.MetricTypes {
 		group := &report.MetricGroup{
 			Type:          metricType.Type,
 			MetricsByName: make(map[string][]report.MetricData),
+			TrendsByName:  make(map[string]*report.TrendData),
 		}
 		data.MetricGroups[metricType.Type] = group
 
 		for _, metric := range metricType.Metrics {
-			result, _, err := c.Client.Query(ctx, metric.Query, time.Now())
-			if err != nil {
-				log.Printf("警告: 查询指标 %s 失败: %v", metric.Name, err)
-				continue
+			datasources := []string{c.ResolveDatasource(metricType.DatasourceFor(metric))}
+			if c.config.Fleet.Enabled {
+				datasources = c.config.FleetClusters()
 			}
-			log.Printf("指标 [%s] 查询结果: %+v", metric.Name, result)
-
-			switch v := result.(type) {
-			case model.Vector:
-				metrics := make([]report.MetricData, 0, len(v))
-				for _, sample := range v {
-					log.Printf("指标 [%s] 原始数据: %+v, 值: %+v", metric.Name, sample.Metric, sample.Value)
+			for _, datasource := range datasources {
+				jobs = append(jobs, collectJob{
+					group:      group,
+					metric:     metric,
+					datasource: datasource,
+					at:         data.Timestamp,
+				})
+			}
+		}
+	}
 
-					availableLabels := make(map[string]string)
-					for labelName, labelValue := range sample.Metric {
-						availableLabels[string(labelName)] = string(labelValue)
+	results := make([]collectResult, len(jobs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job collectJob) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					log.Printf("警告: 指标 [%s] 未开始查询即已取消: %v", job.metric.Name, ctx.Err())
+					results[i].metrics = []report.MetricData{
+						newStatusRow(job.metric, "error", fmt.Sprintf("采集已取消，未执行查询: %v", ctx.Err())),
 					}
+					return
+				}
+				log.Printf("警告: 指标 [%s] 未开始查询即已超时", job.metric.Name)
+				results[i].metrics = []report.MetricData{
+					newStatusRow(job.metric, "error", fmt.Sprintf("整体采集超时（%s），未执行查询", timeout)),
+				}
+				results[i].timeouts = append(results[i].timeouts, newTimedOutMetric(job, job.metric.Query, timeout))
+				return
+			}
+			results[i] = c.collectMetric(ctx, job, queryTimeout)
+		}(i, job)
+	}
+	wg.Wait()
 
-					labels := make([]report.LabelData, 0, len(metric.Labels))
-					for configLabel, configAlias := range metric.Labels {
-						labelValue := "-"
-						if rawValue, exists := availableLabels[configLabel]; exists && rawValue != "" {
-							labelValue = rawValue
-						} else {
-							log.Printf("警告: 指标 [%s] 标签 [%s] 缺失或为空", metric.Name, configLabel)
-						}
-
-						labels = append(labels, report.LabelData{
-							Name:  configLabel,
-							Alias: configAlias,
-							Value: labelValue,
-						})
-					}
+	// 按配置顺序汇总结果
+	datasources := make(map[string]bool)
+	for i, job := range jobs {
+		result := results[i]
+		for j := range result.metrics {
+			result.metrics[j].Datasource = job.datasource
+		}
+		if !datasources[job.datasource] {
+			datasources[job.datasource] = true
+			data.Datasources = append(data.Datasources, job.datasource)
+		}
+		if result.metrics != nil {
+			job.group.MetricsByName[job.metric.Name] = append(job.group.MetricsByName[job.metric.Name], result.metrics...)
+		}
+		if result.trend != nil {
+			if data.Fleet {
+				// 多集群巡检时同一指标的趋势合并到一张图中，序列名称加上集群前缀
+				for j := range result.trend.Series {
+					result.trend.Series[j].Name = job.datasource + ": " + result.trend.Series[j].Name
+				}
+			}
+			if existing, ok := job.group.TrendsByName[job.metric.Name]; ok {
+				existing.Series = append(existing.Series, result.trend.Series...)
+			} else {
+				job.group.TrendsByName[job.metric.Name] = result.trend
+			}
+		}
+		data.TimedOutMetrics = append(data.TimedOutMetrics, result.timeouts...)
+	}
+	if len(data.TimedOutMetrics) > 0 {
+		log.Printf("警告: %d 个查询超时", len(data.TimedOutMetrics))
+	}
+	return data, nil
+}
 
-					if !validateLabels(labels) {
-						log.Printf("警告: 指标 [%s] 标签数据不完整，跳过该条记录", metric.Name)
-						continue
-					}
+// collectMetric 查询单个指标的即时数据和趋势数据
+func (c *Collector) collectMetric(ctx context.Context, job collectJob, queryTimeout time.Duration) collectResult {
+	var res collectResult
+	metric := job.metric
 
-					metricData := report.MetricData{
-						Name:        metric.Name,
-						Description: metric.Description,
-						Value:       float64(sample.Value),
-						Threshold:   metric.Threshold,
-						Unit:        metric.Unit,
-						Status:      getStatus(float64(sample.Value), metric.Threshold, metric.ThresholdType),
-						StatusText:  report.GetStatusText(getStatus(float64(sample.Value), metric.Threshold, metric.ThresholdType)),
-						Timestamp:   time.Now(),
-						Labels:      labels,
-					}
+	client, err := c.ClientFor(job.datasource)
+	if err != nil {
+		log.Printf("警告: 指标 [%s] 的数据源不可用: %v", metric.Name, err)
+		res.metrics = []report.MetricData{newStatusRow(metric, "error", err.Error())}
+		return res
+	}
 
-					if err := validateMetricData(metricData, metric.Labels); err != nil {
-						log.Printf("警告: 指标 [%s] 数据验证失败: %v", metric.Name, err)
-						continue
-					}
+	queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	result, _, err := client.Query(queryCtx, metric.Query, job.at)
+	timedOut := errors.Is(queryCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	if err != nil {
+		if timedOut {
+			log.Printf("警告: 查询指标 %s 超时: %v", metric.Name, err)
+			res.timeouts = append(res.timeouts, newTimedOutMetric(job, metric.Query, queryTimeout))
+			res.metrics = []report.MetricData{newStatusRow(metric, "error", fmt.Sprintf("查询超时（%s）: %v", queryTimeout, err))}
+			// 即时查询已超时，趋势查询的范围更大，不再执行
+			return res
+		}
+		log.Printf("警告: 查询指标 %s 失败: %v", metric.Name, err)
+		res.metrics = []report.MetricData{newStatusRow(metric, "error", err.Error())}
+	} else {
+		log.Printf("指标 [%s] 查询结果: %+v", metric.Name, result)
+		v, err := toVector(result, metric.Reduce)
+		if err != nil {
+			log.Printf("警告: 指标 [%s] 查询结果无法处理: %v", metric.Name, err)
+			res.metrics = []report.MetricData{newStatusRow(metric, "error", err.Error())}
+		} else {
+			res.metrics = buildMetricRows(metric, v)
+			if len(v) == 0 {
+				log.Printf("警告: 指标 [%s] 查询结果为空", metric.Name)
+				res.metrics = []report.MetricData{newStatusRow(metric, "nodata", "查询结果为空")}
+			} else if len(res.metrics) == 0 {
+				res.metrics = []report.MetricData{newStatusRow(metric, "nodata",
+					fmt.Sprintf("查询返回 %d 条记录，但配置的标签均缺失或为空", len(v)))}
+			}
+		}
+	}
 
-					metrics = append(metrics, metricData)
-				}
-				group.MetricsByName[metric.Name] = metrics
+	// 整体采集已超时或取消时不再查询趋势数据
+	if ctx.Err() != nil {
+		return res
+	}
+	trendCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+	trend, err := c.collectTrend(trendCtx, client, metric, job.at)
+	timedOut = errors.Is(trendCtx.Err(), context.DeadlineExceeded)
+	cancel()
+	if err != nil {
+		if timedOut {
+			log.Printf("警告: 查询指标 %s 趋势数据超时: %v", metric.Name, err)
+			res.timeouts = append(res.timeouts, newTimedOutMetric(job, trendQuery(metric), queryTimeout))
+		} else {
+			log.Printf("警告: 查询指标 %s 趋势数据失败: %v", metric.Name, err)
+		}
+	} else {
+		res.trend = trend
+	}
+	return res
+}
+
+// buildMetricRows 将即时查询结果转换为报告数据
+func buildMetricRows(metric config.MetricConfig, v model.Vector) []report.MetricData {
+	metrics := make([]report.MetricData, 0, len(v))
+	for _, sample := range v {
+		log.Printf("指标 [%s] 原始数据: %+v, 值: %+v", metric.Name, sample.Metric, sample.Value)
+
+		availableLabels := make(map[string]string)
+		for labelName, labelValue := range sample.Metric {
+			availableLabels[string(labelName)] = string(labelValue)
+		}
+		// 标量或聚合结果（如 count(up == 0)）没有标签，作为一条不带标签的记录保留
+		unlabelled := len(availableLabels) == 0
+
+		labels := make([]report.LabelData, 0, len(metric.Labels))
+		for configLabel, configAlias := range metric.Labels {
+			labelValue := "-"
+			if rawValue, exists := availableLabels[configLabel]; exists && rawValue != "" {
+				labelValue = rawValue
+			} else if !unlabelled {
+				log.Printf("警告: 指标 [%s] 标签 [%s] 缺失或为空", metric.Name, configLabel)
 			}
+
+			labels = append(labels, report.LabelData{
+				Name:  configLabel,
+				Alias: configAlias,
+				Value: labelValue,
+			})
+		}
+
+		if !unlabelled && !validateLabels(labels) {
+			log.Printf("警告: 指标 [%s] 标签数据不完整，跳过该条记录", metric.Name)
+			continue
+		}
+
+		effective, override := ApplyOverrides(metric, availableLabels)
+		status := EvaluateStatus(effective, float64(sample.Value), availableLabels, sample.Timestamp.Time())
+		metricData := report.MetricData{
+			Name:          metric.Name,
+			Description:   metric.Description,
+			Value:         float64(sample.Value),
+			Threshold:     DisplayThreshold(effective),
+			ThresholdDesc: DescribeThreshold(effective),
+			Override:      override,
+			Unit:          metric.Unit,
+			Status:        status,
+			StatusText:    report.GetStatusText(status),
+			Timestamp:     time.Now(),
+			Labels:        labels,
+			Query:         metric.Query,
+		}
+
+		if unlabelled {
+			metrics = append(metrics, metricData)
+			continue
+		}
+
+		if err := validateMetricData(metricData, metric.Labels); err != nil {
+			log.Printf("警告: 指标 [%s] 数据验证失败: %v", metric.Name, err)
+			continue
 		}
+
+		metrics = append(metrics, metricData)
+	}
+	return metrics
+}
+
+// newStatusRow 创建表示无数据或查询失败的记录，标签以 "-" 占位以保持表格结构
+func newStatusRow(metric config.MetricConfig, status, message string) report.MetricData {
+	labels := make([]report.LabelData, 0, len(metric.Labels))
+	for configLabel, configAlias := range metric.Labels {
+		labels = append(labels, report.LabelData{
+			Name:  configLabel,
+			Alias: configAlias,
+			Value: "-",
+		})
+	}
+
+	return report.MetricData{
+		Name:        metric.Name,
+		Description: metric.Description,
+		Threshold:   DisplayThreshold(metric),
+		Unit:        metric.Unit,
+		Status:      status,
+		StatusText:  report.GetStatusText(status),
+		Timestamp:   time.Now(),
+		Labels:      labels,
+		Query:       metric.Query,
+		Error:       message,
+	}
+}
+
+// newTimedOutMetric 创建超时记录
+func newTimedOutMetric(job collectJob, query string, timeout time.Duration) report.TimedOutMetric {
+	return report.TimedOutMetric{
+		Type:       job.group.Type,
+		Name:       job.metric.Name,
+		Datasource: job.datasource,
+		Query:      query,
+		Timeout:    timeout,
 	}
-	return data, nil
 }
 
 // validateMetricData 验证指标数据的完整性
@@ -137,47 +361,6 @@ func validateMetricData(data report.MetricData, configLabels map[string]string)
 	return nil
 }
 
-// getStatus 获取状态
-func getStatus(value, threshold float64, thresholdType string) string {
-	if thresholdType == "" {
-		thresholdType = "greater"
-	}
-	switch thresholdType {
-	case "greater":
-		if value > threshold {
-			return "critical"
-		} else if value >= threshold*0.8 {
-			return "warning"
-		}
-	case "greater_equal":
-		if value >= threshold {
-			return "critical"
-		} else if value >= threshold*0.8 {
-			return "warning"
-		}
-	case "less":
-		if value < threshold {
-			return "normal"
-		} else if value <= threshold*1.2 {
-			return "warning"
-		}
-	case "less_equal":
-		if value <= threshold {
-			return "normal"
-		} else if value <= threshold*1.2 {
-			return "warning"
-		}
-	case "equal":
-		if value == threshold {
-			return "normal"
-		} else if value > threshold {
-			return "critical"
-		}
-		return "critical"
-	}
-	return "normal"
-}
-
 // validateLabels 验证标签数据的完整性
 func validateLabels(labels []report.LabelData) bool {
 	for _, label := range labels {
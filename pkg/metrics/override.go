@@ -0,0 +1,200 @@
+package metrics
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"PromAI/pkg/config"
+)
+
+// labelMatcher 单个标签匹配条件
+type labelMatcher struct {
+	name  string
+	op    string // =, !=, =~, !~
+	value string
+	re    *regexp.Regexp
+}
+
+func (m labelMatcher) matches(labels map[string]string) bool {
+	value := labels[m.name]
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// parsedMatchers 已解析的匹配器，按 match 字符串缓存
+var parsedMatchers sync.Map
+
+// labelNamePattern Prometheus 标签名的格式
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// parseMatchers 解析 PromQL 风格的标签匹配器，例如 mountpoint=~"/data.*", device!="tmpfs"
+// 与 Prometheus 一致，正则表达式需要完整匹配标签值
+func parseMatchers(input string) ([]labelMatcher, error) {
+	if cached, ok := parsedMatchers.Load(input); ok {
+		return cached.([]labelMatcher), nil
+	}
+
+	s := strings.TrimSpace(input)
+	if strings.HasPrefix(s, "{") != strings.HasSuffix(s, "}") {
+		return nil, fmt.Errorf("unbalanced braces in %q", input)
+	}
+	s = strings.TrimSuffix(strings.TrimPrefix(s, "{"), "}")
+
+	var matchers []labelMatcher
+	for {
+		s = strings.TrimLeft(s, " \t,")
+		if s == "" {
+			break
+		}
+
+		// 标签名
+		end := strings.IndexAny(s, "=!")
+		if end <= 0 {
+			return nil, fmt.Errorf("invalid matcher %q", s)
+		}
+		m := labelMatcher{name: strings.TrimSpace(s[:end])}
+		if !labelNamePattern.MatchString(m.name) {
+			return nil, fmt.Errorf("invalid label name %q", m.name)
+		}
+		s = s[end:]
+
+		// 操作符
+		for _, op := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(s, op) {
+				m.op = op
+				s = strings.TrimSpace(s[len(op):])
+				break
+			}
+		}
+		if m.op == "" {
+			return nil, fmt.Errorf("invalid operator in matcher for label %q", m.name)
+		}
+
+		// 值，支持双引号、单引号、反引号或不带引号
+		switch {
+		case strings.HasPrefix(s, `"`):
+			quoted, err := strconv.QuotedPrefix(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for label %q: %w", m.name, err)
+			}
+			if m.value, err = strconv.Unquote(quoted); err != nil {
+				return nil, fmt.Errorf("invalid value for label %q: %w", m.name, err)
+			}
+			s = s[len(quoted):]
+		case strings.HasPrefix(s, "'"), strings.HasPrefix(s, "`"):
+			end := strings.IndexByte(s[1:], s[0])
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated value for label %q", m.name)
+			}
+			m.value = s[1 : end+1]
+			s = s[end+2:]
+		default:
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			m.value = strings.TrimSpace(s[:end])
+			if strings.ContainsAny(m.value, "\"'`") {
+				return nil, fmt.Errorf("invalid value for label %q: %s", m.name, m.value)
+			}
+			s = s[end:]
+		}
+
+		// 多个条件之间必须用逗号分隔
+		s = strings.TrimLeft(s, " \t")
+		if s != "" && !strings.HasPrefix(s, ",") {
+			return nil, fmt.Errorf("expected , after value of label %q, got %q", m.name, s)
+		}
+
+		if m.op == "=~" || m.op == "!~" {
+			re, err := regexp.Compile("^(?:" + m.value + ")$")
+			if err != nil {
+				return nil, fmt.Errorf("invalid regexp for label %q: %w", m.name, err)
+			}
+			m.re = re
+		}
+		matchers = append(matchers, m)
+	}
+
+	if len(matchers) == 0 {
+		return nil, fmt.Errorf("empty matcher")
+	}
+	parsedMatchers.Store(input, matchers)
+	return matchers, nil
+}
+
+// matchOverride 判断标签是否满足覆盖规则的所有匹配条件
+func matchOverride(override config.ThresholdOverride, labels map[string]string) (bool, error) {
+	matchers, err := parseMatchers(override.Match)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range matchers {
+		if !m.matches(labels) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ApplyOverrides 返回序列实际使用的阈值配置和命中的覆盖规则名称，没有命中时名称为空
+// 返回的配置不再包含 overrides，可以直接用于状态判断
+func ApplyOverrides(metric config.MetricConfig, labels map[string]string) (config.MetricConfig, string) {
+	overrides := metric.Overrides
+	metric.Overrides = nil
+
+	for _, override := range overrides {
+		matched, err := matchOverride(override, labels)
+		if err != nil {
+			continue
+		}
+		if matched {
+			return mergeOverride(metric, override), override.DisplayName()
+		}
+	}
+	return metric, ""
+}
+
+// mergeOverride 使用覆盖规则中配置的字段替换指标的阈值配置
+func mergeOverride(metric config.MetricConfig, override config.ThresholdOverride) config.MetricConfig {
+	if override.Threshold != nil {
+		metric.Threshold = *override.Threshold
+		// 覆盖了 threshold 时，未同时覆盖的显式阈值不再沿用
+		metric.CriticalThreshold = nil
+		metric.WarningThreshold = nil
+	}
+	if override.ThresholdType != "" {
+		metric.ThresholdType = override.ThresholdType
+	}
+	if override.WarningThreshold != nil {
+		metric.WarningThreshold = override.WarningThreshold
+	}
+	if override.CriticalThreshold != nil {
+		metric.CriticalThreshold = override.CriticalThreshold
+	}
+	if override.Min != nil {
+		metric.Min = override.Min
+	}
+	if override.Max != nil {
+		metric.Max = override.Max
+	}
+	if len(override.Bands) > 0 {
+		metric.Bands = override.Bands
+	}
+	if override.StatusRule != "" {
+		metric.StatusRule = override.StatusRule
+	}
+	return metric
+}
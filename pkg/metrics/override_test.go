@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"testing"
+)
+
+func TestParseMatchers(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []labelMatcher // 只比较 name、op 和 value
+	}{
+		{`mountpoint="/data"`, []labelMatcher{{name: "mountpoint", op: "=", value: "/data"}}},
+		{`{mountpoint="/data"}`, []labelMatcher{{name: "mountpoint", op: "=", value: "/data"}}},
+		{` { device != "tmpfs" } `, []labelMatcher{{name: "device", op: "!=", value: "tmpfs"}}},
+		{`mountpoint=~"/data.*", device!~'tmp.*'`, []labelMatcher{
+			{name: "mountpoint", op: "=~", value: "/data.*"},
+			{name: "device", op: "!~", value: "tmp.*"},
+		}},
+		{"instance=`10.0.0.1:9100`", []labelMatcher{{name: "instance", op: "=", value: "10.0.0.1:9100"}}},
+		{`instance=10.0.0.1:9100,job=node`, []labelMatcher{
+			{name: "instance", op: "=", value: "10.0.0.1:9100"},
+			{name: "job", op: "=", value: "node"},
+		}},
+		{`path="a,b", job="node",`, []labelMatcher{
+			{name: "path", op: "=", value: "a,b"},
+			{name: "job", op: "=", value: "node"},
+		}},
+		{`label="say \"hi\""`, []labelMatcher{{name: "label", op: "=", value: `say "hi"`}}},
+		{`env=""`, []labelMatcher{{name: "env", op: "=", value: ""}}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseMatchers(tt.input)
+		if err != nil {
+			t.Errorf("parseMatchers(%q): %v", tt.input, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("parseMatchers(%q) = %d matchers, want %d", tt.input, len(got), len(tt.want))
+			continue
+		}
+		for i := range got {
+			if got[i].name != tt.want[i].name || got[i].op != tt.want[i].op || got[i].value != tt.want[i].value {
+				t.Errorf("parseMatchers(%q)[%d] = %s %s %q, want %s %s %q", tt.input, i,
+					got[i].name, got[i].op, got[i].value, tt.want[i].name, tt.want[i].op, tt.want[i].value)
+			}
+		}
+	}
+}
+
+func TestParseMatchersInvalid(t *testing.T) {
+	for _, input := range []string{
+		``,
+		`{}`,
+		` , `,
+		`mountpoint`,
+		`="/data"`,
+		`mount point="/data"`,
+		`1device="sda"`,
+		`device=="sda"`,
+		`device<"sda"`,
+		`device="sda`,
+		`device='sda`,
+		`device="sda" job="node"`,
+		`device=sda"`,
+		`{device="sda"`,
+		`device="sda"}`,
+		`device=~"(sda"`,
+	} {
+		if _, err := parseMatchers(input); err == nil {
+			t.Errorf("parseMatchers(%q) succeeded, want error", input)
+		}
+	}
+}
+
+func TestLabelMatcherMatches(t *testing.T) {
+	labels := map[string]string{"mountpoint": "/data/disk1", "device": "sda1"}
+	tests := []struct {
+		match string
+		want  bool
+	}{
+		{`mountpoint="/data/disk1"`, true},
+		{`mountpoint="/data"`, false},
+		{`mountpoint!="/data"`, true},
+		{`mountpoint=~"/data.*"`, true},
+		{`mountpoint=~"/data"`, false}, // 正则需要完整匹配
+		{`mountpoint=~"disk1"`, false},
+		{`device!~"sd.*"`, false},
+		{`device=~"sda1|sdb1", mountpoint=~"/data/.*"`, true},
+		{`device="sda1", mountpoint="/"`, false},
+		{`missing=""`, true}, // 不存在的标签视为空值
+		{`missing!=""`, false},
+	}
+
+	for _, tt := range tests {
+		matchers, err := parseMatchers(tt.match)
+		if err != nil {
+			t.Fatalf("parseMatchers(%q): %v", tt.match, err)
+		}
+		got := true
+		for _, m := range matchers {
+			got = got && m.matches(labels)
+		}
+		if got != tt.want {
+			t.Errorf("%s matches %v = %v, want %v", tt.match, labels, got, tt.want)
+		}
+	}
+}
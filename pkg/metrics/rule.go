@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+
+	"PromAI/pkg/config"
+)
+
+// rulePrograms 已编译的状态规则，按表达式缓存
+var rulePrograms sync.Map
+
+// compileRule 编译状态规则表达式
+func compileRule(rule string) (*vm.Program, error) {
+	if program, ok := rulePrograms.Load(rule); ok {
+		return program.(*vm.Program), nil
+	}
+	program, err := expr.Compile(rule, expr.Env(ruleEnv(config.MetricConfig{}, 0, nil, time.Time{})))
+	if err != nil {
+		return nil, err
+	}
+	rulePrograms.Store(rule, program)
+	return program, nil
+}
+
+// ruleEnv 状态规则表达式可以使用的变量
+func ruleEnv(metric config.MetricConfig, value float64, labels map[string]string, ts time.Time) map[string]interface{} {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	return map[string]interface{}{
+		"value":              value,
+		"labels":             labels,
+		"threshold":          metric.Threshold,
+		"warning_threshold":  WarningThreshold(metric),
+		"critical_threshold": CriticalThreshold(metric),
+		"hour":               ts.Hour(),
+		"weekday":            int(ts.Weekday()), // 0 表示周日
+		"weekend":            ts.Weekday() == time.Saturday || ts.Weekday() == time.Sunday,
+	}
+}
+
+// evaluateRule 执行状态规则，返回的状态为空表示规则未给出结论
+// 表达式可以返回状态字符串（normal/warning/critical，空字符串表示未给出结论），
+// 也可以返回布尔值（true 表示 critical，false 表示未给出结论）
+func evaluateRule(metric config.MetricConfig, value float64, labels map[string]string, ts time.Time) (string, error) {
+	program, err := compileRule(metric.StatusRule)
+	if err != nil {
+		return "", fmt.Errorf("compiling status_rule: %w", err)
+	}
+
+	output, err := expr.Run(program, ruleEnv(metric, value, labels, ts))
+	if err != nil {
+		return "", fmt.Errorf("running status_rule: %w", err)
+	}
+
+	switch result := output.(type) {
+	case nil:
+		return "", nil
+	case bool:
+		if result {
+			return StatusCritical, nil
+		}
+		return "", nil
+	case string:
+		switch result {
+		case "", StatusNormal, StatusWarning, StatusCritical:
+			return result, nil
+		}
+		return "", fmt.Errorf("status_rule returned invalid status %q", result)
+	default:
+		return "", fmt.Errorf("status_rule returned unsupported type %T", output)
+	}
+}
+
+// EvaluateStatus 判断一个采样值的状态
+// 先按标签应用 overrides，配置了 status_rule 时优先使用规则的结论，规则未给出结论或执行失败时使用 threshold_type 判断
+func EvaluateStatus(metric config.MetricConfig, value float64, labels map[string]string, ts time.Time) string {
+	if len(metric.Overrides) > 0 {
+		metric, _ = ApplyOverrides(metric, labels)
+	}
+	if metric.StatusRule != "" {
+		status, err := evaluateRule(metric, value, labels, ts)
+		if err != nil {
+			log.Printf("警告: 指标 [%s] 状态规则执行失败，使用阈值判断: %v", metric.Name, err)
+		} else if status != "" {
+			return status
+		}
+	}
+	return EvaluateThreshold(value, metric)
+}
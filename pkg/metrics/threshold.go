@@ -0,0 +1,260 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"PromAI/pkg/config"
+	"PromAI/pkg/report"
+)
+
+// 指标状态
+const (
+	StatusNormal   = "normal"
+	StatusWarning  = "warning"
+	StatusCritical = "critical"
+)
+
+const (
+	// DefaultWarningFactor 未配置 warning_threshold 时 greater/greater_equal 的警告阈值系数，
+	// 即值达到严重阈值的 80% 时为警告
+	DefaultWarningFactor = 0.8
+	// DefaultLessCriticalFactor 未配置 critical_threshold 时 less/less_equal 的严重阈值系数，
+	// 即值超过警告阈值（threshold）的 120% 时为严重
+	DefaultLessCriticalFactor = 1.2
+)
+
+// isLessType less/less_equal 表示值低于 threshold 才正常，threshold 即警告阈值
+func isLessType(metric config.MetricConfig) bool {
+	return metric.ThresholdType == "less" || metric.ThresholdType == "less_equal"
+}
+
+// CriticalThreshold 返回指标的严重阈值，未配置 critical_threshold 时使用 threshold，
+// less/less_equal 为警告阈值按默认系数推算
+func CriticalThreshold(metric config.MetricConfig) float64 {
+	if metric.CriticalThreshold != nil {
+		return *metric.CriticalThreshold
+	}
+	if isLessType(metric) {
+		return WarningThreshold(metric) * DefaultLessCriticalFactor
+	}
+	return metric.Threshold
+}
+
+// WarningThreshold 返回指标的警告阈值，未配置 warning_threshold 时 greater/greater_equal 按默认系数由严重阈值推算，
+// less/less_equal 使用 threshold
+func WarningThreshold(metric config.MetricConfig) float64 {
+	if metric.WarningThreshold != nil {
+		return *metric.WarningThreshold
+	}
+	if isLessType(metric) {
+		return metric.Threshold
+	}
+	return CriticalThreshold(metric) * DefaultWarningFactor
+}
+
+// DisplayThreshold 返回报告和看板中显示的阈值，less/less_equal 为正常值的上限，其他类型为严重阈值
+func DisplayThreshold(metric config.MetricConfig) float64 {
+	if isLessType(metric) {
+		return WarningThreshold(metric)
+	}
+	return CriticalThreshold(metric)
+}
+
+// EvaluateThreshold 根据指标的阈值配置判断状态，返回 normal、warning 或 critical
+// 报告和健康看板共用此逻辑，保证同一个值在两处的状态一致
+func EvaluateThreshold(value float64, metric config.MetricConfig) string {
+	critical := CriticalThreshold(metric)
+	warning := WarningThreshold(metric)
+
+	switch metric.ThresholdType {
+	case "between":
+		// 值在 [min, max] 区间内正常
+		if !inRange(value, metric.Min, metric.Max) {
+			return StatusCritical
+		}
+	case "outside":
+		// 值在 [min, max] 区间外正常
+		if inRange(value, metric.Min, metric.Max) {
+			return StatusCritical
+		}
+	case "bands":
+		// 按顺序匹配第一个包含该值的分段，没有匹配的分段时为正常
+		for _, band := range metric.Bands {
+			if (band.Min == nil || value >= *band.Min) && (band.Max == nil || value < *band.Max) {
+				return band.Status
+			}
+		}
+	case "greater_equal":
+		// 值大于等于阈值时告警
+		if value >= critical {
+			return StatusCritical
+		} else if value >= warning {
+			return StatusWarning
+		}
+	case "less":
+		// 值小于阈值时正常，例如：响应时间 < 200ms，超过严重阈值时为严重
+		if value > critical {
+			return StatusCritical
+		} else if value >= warning {
+			return StatusWarning
+		}
+	case "less_equal":
+		// 值小于等于阈值时正常
+		if value > critical {
+			return StatusCritical
+		} else if value > warning {
+			return StatusWarning
+		}
+	case "equal":
+		// 值必须等于阈值才正常
+		if value != critical {
+			return StatusCritical
+		}
+	case "not_equal":
+		// 值不等于阈值才正常
+		if value == critical {
+			return StatusCritical
+		}
+	default:
+		// greater 及未配置时：值大于阈值时告警，例如：CPU使用率 > 80%
+		if value > critical {
+			return StatusCritical
+		} else if value >= warning {
+			return StatusWarning
+		}
+	}
+	return StatusNormal
+}
+
+// inRange 判断值是否在闭区间 [min, max] 内，min/max 为 nil 表示不限
+func inRange(value float64, min, max *float64) bool {
+	return (min == nil || value >= *min) && (max == nil || value <= *max)
+}
+
+// ValidateThreshold 检查指标的阈值配置和状态规则是否有效
+func ValidateThreshold(metric config.MetricConfig) error {
+	if metric.StatusRule != "" {
+		if _, err := compileRule(metric.StatusRule); err != nil {
+			return fmt.Errorf("invalid status_rule: %w", err)
+		}
+	}
+
+	for i, override := range metric.Overrides {
+		if _, err := parseMatchers(override.Match); err != nil {
+			return fmt.Errorf("override %d: invalid match: %w", i, err)
+		}
+		base := metric
+		base.Overrides = nil
+		if err := ValidateThreshold(mergeOverride(base, override)); err != nil {
+			return fmt.Errorf("override %d (%s): %w", i, override.DisplayName(), err)
+		}
+	}
+
+	switch metric.ThresholdType {
+	case "", "greater", "greater_equal", "less", "less_equal":
+		// 各类型都是值越大越严重，警告阈值必须小于严重阈值，否则永远不会出现警告
+		// 由默认系数推算的阈值不检查，例如负数阈值乘以系数后顺序会颠倒
+		explicit := metric.WarningThreshold != nil
+		if isLessType(metric) {
+			explicit = metric.CriticalThreshold != nil
+		}
+		if warning, critical := WarningThreshold(metric), CriticalThreshold(metric); explicit && warning >= critical {
+			return fmt.Errorf("warning threshold %v must be less than critical threshold %v", warning, critical)
+		}
+		return nil
+	case "equal", "not_equal":
+		return nil
+	case "between", "outside":
+		if metric.Min == nil && metric.Max == nil {
+			return fmt.Errorf("threshold_type %s requires min or max", metric.ThresholdType)
+		}
+		if metric.Min != nil && metric.Max != nil && *metric.Min > *metric.Max {
+			return fmt.Errorf("min %v is greater than max %v", *metric.Min, *metric.Max)
+		}
+		return nil
+	case "bands":
+		if len(metric.Bands) == 0 {
+			return fmt.Errorf("threshold_type bands requires at least one band")
+		}
+		for i, band := range metric.Bands {
+			switch band.Status {
+			case StatusNormal, StatusWarning, StatusCritical:
+			default:
+				return fmt.Errorf("band %d has invalid status %q", i, band.Status)
+			}
+			if band.Min != nil && band.Max != nil && *band.Min >= *band.Max {
+				return fmt.Errorf("band %d min %v is not less than max %v", i, *band.Min, *band.Max)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown threshold_type: %s", metric.ThresholdType)
+	}
+}
+
+// ValidateConfig 检查所有指标的阈值配置和聚合方式
+func ValidateConfig(cfg *config.Config) error {
+	for _, metricType := range cfg.MetricTypes {
+		for _, metric := range metricType.Metrics {
+			if err := ValidateThreshold(metric); err != nil {
+				return fmt.Errorf("metric %s/%s: %w", metricType.Type, metric.Name, err)
+			}
+			if err := validateReduce(metric.Reduce); err != nil {
+				return fmt.Errorf("metric %s/%s: %w", metricType.Type, metric.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// ThresholdLines 返回趋势图中需要绘制的阈值线
+func ThresholdLines(metric config.MetricConfig) []report.TrendThreshold {
+	switch metric.ThresholdType {
+	case "between", "outside":
+		var lines []report.TrendThreshold
+		if metric.Min != nil {
+			lines = append(lines, report.TrendThreshold{Name: "下限", Value: *metric.Min})
+		}
+		if metric.Max != nil {
+			lines = append(lines, report.TrendThreshold{Name: "上限", Value: *metric.Max})
+		}
+		return lines
+	case "bands":
+		return nil
+	case "equal", "not_equal":
+		return []report.TrendThreshold{{Name: "阈值", Value: CriticalThreshold(metric)}}
+	default:
+		return []report.TrendThreshold{
+			{Name: "严重阈值", Value: CriticalThreshold(metric)},
+			{Name: "警告阈值", Value: WarningThreshold(metric)},
+		}
+	}
+}
+
+// DescribeThreshold 返回区间和分段阈值的简短描述，用于页面显示，其他类型返回空字符串
+func DescribeThreshold(metric config.MetricConfig) string {
+	bound := func(v *float64, infinity string) string {
+		if v == nil {
+			return infinity
+		}
+		return strconv.FormatFloat(*v, 'f', -1, 64) + metric.Unit
+	}
+
+	switch metric.ThresholdType {
+	case "between":
+		return fmt.Sprintf("正常区间: [%s, %s]", bound(metric.Min, "-∞"), bound(metric.Max, "+∞"))
+	case "outside":
+		return fmt.Sprintf("异常区间: [%s, %s]", bound(metric.Min, "-∞"), bound(metric.Max, "+∞"))
+	case "bands":
+		parts := make([]string, 0, len(metric.Bands))
+		for _, band := range metric.Bands {
+			parts = append(parts, fmt.Sprintf("[%s, %s) %s",
+				bound(band.Min, "-∞"), bound(band.Max, "+∞"), report.GetStatusText(band.Status)))
+		}
+		return "分段: " + strings.Join(parts, "; ")
+	default:
+		return ""
+	}
+}
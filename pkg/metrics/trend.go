@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+	"github.com/prometheus/common/model"
+
+	"PromAI/pkg/config"
+	"PromAI/pkg/report"
+)
+
+// collectTrend 查询指标的趋势数据
+// 优先使用 trend_query，未配置时若开启了 trend.enabled 则使用 query，否则不生成趋势图
+func (c *Collector) collectTrend(ctx context.Context, client PrometheusAPI, metric config.MetricConfig, at time.Time) (*report.TrendData, error) {
+	if metric.TrendQuery == "" && !c.config.Trend.Enabled {
+		return nil, nil
+	}
+	query := trendQuery(metric)
+
+	window, step := c.config.Trend.Window()
+	end := at.Truncate(step)
+	start := end.Add(-window)
+
+	result, _, err := client.QueryRange(ctx, query, v1.Range{
+		Start: start,
+		End:   end,
+		Step:  step,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("querying trend: %w", err)
+	}
+
+	matrix, ok := result.(model.Matrix)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trend result type: %T", result)
+	}
+	log.Printf("指标 [%s] 趋势查询返回 %d 个时间序列", metric.Name, len(matrix))
+
+	return buildTrendData(matrix, metric, start, end, step), nil
+}
+
+// trendQuery 返回指标的趋势查询语句
+func trendQuery(metric config.MetricConfig) string {
+	if metric.TrendQuery != "" {
+		return metric.TrendQuery
+	}
+	return metric.Query
+}
+
+// buildTrendData 将区间查询结果对齐到固定的时间轴上
+func buildTrendData(matrix model.Matrix, metric config.MetricConfig, start, end time.Time, step time.Duration) *report.TrendData {
+	points := int(end.Sub(start)/step) + 1
+	trend := &report.TrendData{
+		Timestamps: make([]time.Time, points),
+		Series:     make([]report.TrendSeries, 0, len(matrix)),
+		Thresholds: ThresholdLines(metric),
+		Unit:       metric.Unit,
+	}
+	for i := range trend.Timestamps {
+		trend.Timestamps[i] = start.Add(time.Duration(i) * step)
+	}
+
+	for _, stream := range matrix {
+		values := make([]*float64, points)
+		for _, sample := range stream.Values {
+			idx := int((sample.Timestamp.Time().Sub(start) + step/2) / step)
+			if idx < 0 || idx >= points {
+				continue
+			}
+			value := float64(sample.Value)
+			values[idx] = &value
+		}
+		trend.Series = append(trend.Series, report.TrendSeries{
+			Name:   seriesName(stream.Metric, metric.Labels),
+			Values: values,
+		})
+	}
+
+	sort.Slice(trend.Series, func(i, j int) bool {
+		return trend.Series[i].Name < trend.Series[j].Name
+	})
+	return trend
+}
+
+// seriesName 使用配置的标签值拼接序列名称，没有可用标签时使用完整的标签集
+func seriesName(m model.Metric, configLabels map[string]string) string {
+	names := make([]string, 0, len(configLabels))
+	for name := range configLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if value, ok := m[model.LabelName(name)]; ok && value != "" {
+			parts = append(parts, string(value))
+		}
+	}
+	if len(parts) == 0 {
+		return m.String()
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,103 @@
+package metrics
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/common/model"
+)
+
+// 区间向量的聚合方式
+const (
+	ReduceLast = "last"
+	ReduceMax  = "max"
+	ReduceMin  = "min"
+	ReduceAvg  = "avg"
+)
+
+// validateReduce 检查聚合方式是否有效，启动时即可发现拼写错误，而不是等到查询返回区间向量时才报错
+func validateReduce(reduce string) error {
+	switch reduce {
+	case "", ReduceLast, ReduceMax, ReduceMin, ReduceAvg:
+		return nil
+	default:
+		return fmt.Errorf("unknown reduce function %q: use last, max, min or avg", reduce)
+	}
+}
+
+// toVector 将任意类型的查询结果统一转换为即时向量
+// 标量和字符串转换为一条不带标签的记录，区间向量按 reduce 聚合为每个序列一条记录
+func toVector(value model.Value, reduce string) (model.Vector, error) {
+	switch v := value.(type) {
+	case model.Vector:
+		return v, nil
+	case *model.Scalar:
+		return model.Vector{&model.Sample{
+			Metric:    model.Metric{},
+			Value:     v.Value,
+			Timestamp: v.Timestamp,
+		}}, nil
+	case *model.String:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v.Value), 64)
+		if err != nil {
+			return nil, fmt.Errorf("string result %q is not a number", v.Value)
+		}
+		return model.Vector{&model.Sample{
+			Metric:    model.Metric{},
+			Value:     model.SampleValue(f),
+			Timestamp: v.Timestamp,
+		}}, nil
+	case model.Matrix:
+		vector := make(model.Vector, 0, len(v))
+		for _, stream := range v {
+			if len(stream.Values) == 0 {
+				continue
+			}
+			reduced, err := reduceValues(stream.Values, reduce)
+			if err != nil {
+				return nil, err
+			}
+			vector = append(vector, &model.Sample{
+				Metric:    stream.Metric,
+				Value:     reduced,
+				Timestamp: stream.Values[len(stream.Values)-1].Timestamp,
+			})
+		}
+		return vector, nil
+	default:
+		return nil, fmt.Errorf("unsupported result type: %s", value.Type())
+	}
+}
+
+// reduceValues 按聚合方式将一个序列的采样点聚合为单个值
+func reduceValues(values []model.SamplePair, reduce string) (model.SampleValue, error) {
+	switch reduce {
+	case "", ReduceLast:
+		return values[len(values)-1].Value, nil
+	case ReduceMax:
+		result := values[0].Value
+		for _, v := range values[1:] {
+			if v.Value > result {
+				result = v.Value
+			}
+		}
+		return result, nil
+	case ReduceMin:
+		result := values[0].Value
+		for _, v := range values[1:] {
+			if v.Value < result {
+				result = v.Value
+			}
+		}
+		return result, nil
+	case ReduceAvg:
+		var sum model.SampleValue
+		for _, v := range values {
+			sum += v.Value
+		}
+		return sum / model.SampleValue(len(values)), nil
+	default:
+		return 0, fmt.Errorf("unknown reduce function: %s", reduce)
+	}
+}
@@ -0,0 +1,255 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"PromAI/pkg/config"
+)
+
+// slackMaxTextLength Slack section 文本的最大长度
+const slackMaxTextLength = 3000
+
+// criticalRows 返回摘要中状态为严重的记录
+func criticalRows(summary Summary) []RowSummary {
+	var rows []RowSummary
+	for _, row := range summary.TopRows {
+		if row.Status == "critical" {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// rowText 记录的文本描述，例如 "基础资源 / CPU使用率（节点=10.0.0.1）：92.00%"
+func rowText(row RowSummary) string {
+	text := row.Type + " / " + row.Name
+	if row.Labels != "" {
+		text += "（" + row.Labels + "）"
+	}
+	return text + fmt.Sprintf("：%.2f%s", row.Value, row.Unit)
+}
+
+// groupText 指标类型统计的文本描述
+func groupText(group GroupSummary) string {
+	return fmt.Sprintf("严重 %d，警告 %d，共 %d 项", group.CriticalCount, group.WarningCount, group.TotalCount)
+}
+
+// absoluteURL 报告链接是否为完整地址，按钮链接要求完整地址
+func absoluteURL(link string) bool {
+	return strings.HasPrefix(link, "http://") || strings.HasPrefix(link, "https://")
+}
+
+// postCard 发送消息卡片
+func postCard(ctx context.Context, client *http.Client, url string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	_, err = sendRequest(ctx, client, http.MethodPost, url, nil, body)
+	return err
+}
+
+// Slack 以 Block Kit 消息发送摘要
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+// NewSlack 创建 Slack 通知
+func NewSlack(cfg config.CardConfig) (*Slack, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	return &Slack{url: cfg.URL, client: &http.Client{}}, nil
+}
+
+// Notify 发送 Slack 消息
+func (s *Slack) Notify(ctx context.Context, n *Notification) error {
+	return postCard(ctx, s.client, s.url, slackMessage(n.Summary))
+}
+
+// slackEscape 转义 Slack mrkdwn 中的特殊字符
+func slackEscape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+// slackText 生成 mrkdwn 文本对象，超过长度限制时截断
+func slackText(text string) map[string]string {
+	if runes := []rune(text); len(runes) > slackMaxTextLength {
+		text = string(runes[:slackMaxTextLength-1]) + "…"
+	}
+	return map[string]string{"type": "mrkdwn", "text": text}
+}
+
+// slackMessage 生成 Block Kit 消息
+func slackMessage(summary Summary) map[string]interface{} {
+	title := "巡检报告：" + summary.StatusText
+	blocks := []interface{}{
+		map[string]interface{}{
+			"type": "header",
+			"text": map[string]string{"type": "plain_text", "text": title},
+		},
+		map[string]interface{}{
+			"type":     "context",
+			"elements": []interface{}{slackText("时间：" + summary.Timestamp.Format("2006-01-02 15:04:05"))},
+		},
+		map[string]interface{}{
+			"type": "section",
+			"fields": []interface{}{
+				slackText(fmt.Sprintf("*严重*\n%d", summary.CriticalCount)),
+				slackText(fmt.Sprintf("*警告*\n%d", summary.WarningCount)),
+				slackText(fmt.Sprintf("*无数据 / 查询失败*\n%d / %d", summary.NoDataCount, summary.ErrorCount)),
+				slackText(fmt.Sprintf("*总记录*\n%d", summary.TotalCount)),
+			},
+		},
+		map[string]interface{}{"type": "divider"},
+	}
+
+	var groups strings.Builder
+	groups.WriteString("*分组统计*")
+	for _, group := range summary.Groups {
+		groups.WriteString("\n• " + slackEscape(group.Type) + "：" + groupText(group))
+	}
+	blocks = append(blocks, map[string]interface{}{"type": "section", "text": slackText(groups.String())})
+
+	if rows := criticalRows(summary); len(rows) > 0 {
+		var critical strings.Builder
+		critical.WriteString("*严重记录*")
+		for _, row := range rows {
+			critical.WriteString("\n• " + slackEscape(rowText(row)))
+		}
+		blocks = append(blocks, map[string]interface{}{"type": "section", "text": slackText(critical.String())})
+	}
+
+	if absoluteURL(summary.ReportURL) {
+		blocks = append(blocks, map[string]interface{}{
+			"type": "actions",
+			"elements": []interface{}{
+				map[string]interface{}{
+					"type": "button",
+					"text": map[string]string{"type": "plain_text", "text": "查看完整报告"},
+					"url":  summary.ReportURL,
+				},
+			},
+		})
+	}
+
+	return map[string]interface{}{
+		"text":   fmt.Sprintf("%s，严重 %d，警告 %d", title, summary.CriticalCount, summary.WarningCount),
+		"blocks": blocks,
+	}
+}
+
+// Teams 以 Adaptive Card 发送摘要
+type Teams struct {
+	url    string
+	client *http.Client
+}
+
+// NewTeams 创建 Microsoft Teams 通知
+func NewTeams(cfg config.CardConfig) (*Teams, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	return &Teams{url: cfg.URL, client: &http.Client{}}, nil
+}
+
+// Notify 发送 Teams 消息
+func (t *Teams) Notify(ctx context.Context, n *Notification) error {
+	return postCard(ctx, t.client, t.url, teamsMessage(n.Summary))
+}
+
+// teamsColor 根据整体状态返回标题颜色
+func teamsColor(status string) string {
+	switch status {
+	case "critical", "error":
+		return "Attention"
+	case "warning":
+		return "Warning"
+	case "nodata":
+		return "Default"
+	default:
+		return "Good"
+	}
+}
+
+// teamsMessage 生成包含 Adaptive Card 的消息
+func teamsMessage(summary Summary) map[string]interface{} {
+	body := []interface{}{
+		map[string]interface{}{
+			"type":   "TextBlock",
+			"size":   "Large",
+			"weight": "Bolder",
+			"color":  teamsColor(summary.Status),
+			"text":   "巡检报告：" + summary.StatusText,
+		},
+		map[string]interface{}{
+			"type":     "TextBlock",
+			"isSubtle": true,
+			"spacing":  "None",
+			"text":     "时间：" + summary.Timestamp.Format("2006-01-02 15:04:05"),
+		},
+		map[string]interface{}{
+			"type": "FactSet",
+			"facts": []interface{}{
+				map[string]string{"title": "严重", "value": fmt.Sprint(summary.CriticalCount)},
+				map[string]string{"title": "警告", "value": fmt.Sprint(summary.WarningCount)},
+				map[string]string{"title": "无数据 / 查询失败", "value": fmt.Sprintf("%d / %d", summary.NoDataCount, summary.ErrorCount)},
+				map[string]string{"title": "总记录", "value": fmt.Sprint(summary.TotalCount)},
+			},
+		},
+	}
+
+	groupFacts := make([]interface{}, 0, len(summary.Groups))
+	for _, group := range summary.Groups {
+		groupFacts = append(groupFacts, map[string]string{"title": group.Type, "value": groupText(group)})
+	}
+	body = append(body,
+		map[string]interface{}{"type": "TextBlock", "weight": "Bolder", "separator": true, "text": "分组统计"},
+		map[string]interface{}{"type": "FactSet", "facts": groupFacts},
+	)
+
+	if rows := criticalRows(summary); len(rows) > 0 {
+		items := make([]interface{}, 0, len(rows))
+		for _, row := range rows {
+			items = append(items, map[string]interface{}{
+				"type":    "TextBlock",
+				"wrap":    true,
+				"spacing": "Small",
+				"color":   "Attention",
+				"text":    "- " + rowText(row),
+			})
+		}
+		body = append(body,
+			map[string]interface{}{"type": "TextBlock", "weight": "Bolder", "separator": true, "text": "严重记录"},
+			map[string]interface{}{"type": "Container", "items": items},
+		)
+	}
+
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"msteams": map[string]string{"width": "Full"},
+		"body":    body,
+	}
+	if absoluteURL(summary.ReportURL) {
+		card["actions"] = []interface{}{
+			map[string]string{"type": "Action.OpenUrl", "title": "查看完整报告", "url": summary.ReportURL},
+		}
+	}
+
+	return map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{
+				"contentType": "application/vnd.microsoft.card.adaptive",
+				"content":     card,
+			},
+		},
+	}
+}
@@ -0,0 +1,412 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"PromAI/pkg/config"
+	"PromAI/pkg/report"
+	"PromAI/templates"
+)
+
+// EmailTemplateFile 邮件正文模板，只使用内联样式，不依赖 JavaScript
+const EmailTemplateFile = "email.html"
+
+// defaultEmailSubject 默认邮件主题模板
+const defaultEmailSubject = `[巡检报告] {{.Summary.StatusText}}：严重 {{.Summary.CriticalCount}}，警告 {{.Summary.WarningCount}}（{{date "2006-01-02 15:04" .Summary.Timestamp}}）`
+
+// TLS 连接方式
+const (
+	TLSModeStartTLS = "starttls"
+	TLSModeTLS      = "tls"
+	TLSModeNone     = "none"
+)
+
+// emailFuncs 邮件正文模板可以使用的函数，状态颜色与报告一致
+var emailFuncs = htmltemplate.FuncMap{
+	"date":             report.FormatDate,
+	"statusColor":      report.StatusColor,
+	"statusBackground": report.StatusBackground,
+}
+
+// emailDelivery 一组收件人及其订阅的指标类型，types 为空表示全部类型
+type emailDelivery struct {
+	to    []string
+	cc    []string
+	types []string
+}
+
+// key 收件人组的标识，用于重试时只发送失败的组
+func (d emailDelivery) key() string {
+	return strings.Join(d.to, ",")
+}
+
+// Email SMTP 邮件通知，正文为内联样式的报告，完整报告作为附件
+type Email struct {
+	cfg        config.EmailConfig
+	port       int
+	tlsMode    string
+	topN       int
+	subject    *template.Template
+	body       *htmltemplate.Template
+	deliveries []emailDelivery
+}
+
+// NewEmail 创建邮件通知，topN 为摘要中列出的异常记录数
+func NewEmail(cfg config.EmailConfig, topN int) (*Email, error) {
+	if cfg.Host == "" || cfg.From == "" {
+		return nil, fmt.Errorf("host and from are required")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("parsing from address: %w", err)
+	}
+
+	e := &Email{cfg: cfg, port: cfg.Port, tlsMode: cfg.TLSMode, topN: topN}
+	if e.port == 0 {
+		e.port = 587
+	}
+	if e.tlsMode == "" && e.port == 465 {
+		e.tlsMode = TLSModeTLS
+	}
+	switch e.tlsMode {
+	case "", TLSModeStartTLS, TLSModeTLS, TLSModeNone:
+	default:
+		return nil, fmt.Errorf("invalid tls_mode %q", cfg.TLSMode)
+	}
+
+	subject := cfg.Subject
+	if subject == "" {
+		subject = defaultEmailSubject
+	}
+	var err error
+	if e.subject, err = parseTemplate(cfg.Name, subject); err != nil {
+		return nil, err
+	}
+	if e.body, err = templates.Parse(htmltemplate.New(EmailTemplateFile).Funcs(emailFuncs), EmailTemplateFile); err != nil {
+		return nil, fmt.Errorf("parsing email template: %w", err)
+	}
+
+	if e.deliveries, err = buildDeliveries(cfg); err != nil {
+		return nil, err
+	}
+	if len(e.deliveries) == 0 {
+		return nil, fmt.Errorf("no recipients configured")
+	}
+	return e, nil
+}
+
+// buildDeliveries 按订阅的指标类型对收件人分组，订阅相同类型的收件人合并为一封邮件
+// to 和 cc 中的收件人接收完整报告，不再重复接收按类型订阅的邮件
+func buildDeliveries(cfg config.EmailConfig) ([]emailDelivery, error) {
+	var deliveries []emailDelivery
+	full := make(map[string]bool)
+	for _, addr := range append(append([]string{}, cfg.To...), cfg.Cc...) {
+		parsed, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("parsing recipient %q: %w", addr, err)
+		}
+		full[parsed.Address] = true
+	}
+	if len(full) > 0 {
+		deliveries = append(deliveries, emailDelivery{to: cfg.To, cc: cfg.Cc})
+	}
+
+	// 每个收件人订阅的指标类型
+	subscriptions := make(map[string][]string)
+	var recipients []string
+	for metricType, addrs := range cfg.TypeRecipients {
+		for _, addr := range addrs {
+			parsed, err := mail.ParseAddress(addr)
+			if err != nil {
+				return nil, fmt.Errorf("parsing recipient %q of type %s: %w", addr, metricType, err)
+			}
+			if full[parsed.Address] {
+				continue
+			}
+			if _, ok := subscriptions[addr]; !ok {
+				recipients = append(recipients, addr)
+			}
+			subscriptions[addr] = append(subscriptions[addr], metricType)
+		}
+	}
+	sort.Strings(recipients)
+
+	groups := make(map[string]int)
+	for _, addr := range recipients {
+		types := subscriptions[addr]
+		sort.Strings(types)
+		key := strings.Join(types, "\x00")
+		if i, ok := groups[key]; ok {
+			deliveries[i].to = append(deliveries[i].to, addr)
+			continue
+		}
+		groups[key] = len(deliveries)
+		deliveries = append(deliveries, emailDelivery{to: []string{addr}, types: types})
+	}
+	return deliveries, nil
+}
+
+// Notify 向每组收件人发送邮件，按类型订阅的收件人只收到订阅类型的内容，附件均为完整报告
+func (e *Email) Notify(ctx context.Context, n *Notification) error {
+	_, err := e.NotifyPending(ctx, n, nil)
+	return err
+}
+
+// NotifyPending 向 pending 中的收件人组发送邮件，pending 为 nil 时发送给所有组
+// 某组发送失败时继续发送其余各组，返回发送失败的组，重试时只发送这些组
+func (e *Email) NotifyPending(ctx context.Context, n *Notification, pending []string) ([]string, error) {
+	var attachment []byte
+	if n.ReportPath != "" {
+		content, err := os.ReadFile(n.ReportPath)
+		if err != nil {
+			return pending, fmt.Errorf("reading report: %w", err)
+		}
+		attachment = content
+	}
+
+	var selected map[string]bool
+	if pending != nil {
+		selected = make(map[string]bool, len(pending))
+		for _, key := range pending {
+			selected[key] = true
+		}
+	}
+
+	failed := []string{}
+	var errs []error
+	for _, delivery := range e.deliveries {
+		key := delivery.key()
+		if selected != nil && !selected[key] {
+			continue
+		}
+		summary, data := n.Summary, n.Data
+		if len(delivery.types) > 0 {
+			data = subsetData(n.Data, delivery.types)
+			if len(data.MetricGroups) == 0 {
+				log.Printf("报告中没有 %v 类型的指标，跳过发送给 %v", delivery.types, delivery.to)
+				continue
+			}
+			summary = BuildSummary(data, "", "", e.topN)
+			summary.ReportURL = n.Summary.ReportURL
+		}
+
+		message, err := e.buildMessage(delivery, &Notification{Summary: summary, Data: data, ReportPath: n.ReportPath}, attachment)
+		if err == nil {
+			err = e.send(ctx, append(append([]string{}, delivery.to...), delivery.cc...), message)
+		}
+		if err != nil {
+			failed = append(failed, key)
+			errs = append(errs, fmt.Errorf("sending to %v: %w", delivery.to, err))
+		}
+	}
+	return failed, errors.Join(errs...)
+}
+
+// subsetData 返回只包含指定指标类型的报告数据
+func subsetData(data *report.ReportData, types []string) *report.ReportData {
+	subset := *data
+	subset.MetricGroups = make(map[string]*report.MetricGroup)
+	subset.TimedOutMetrics = nil
+	subset.ClusterSummary = nil
+	for _, metricType := range types {
+		if group, ok := data.MetricGroups[metricType]; ok {
+			subset.MetricGroups[metricType] = group
+		}
+	}
+	for _, timedOut := range data.TimedOutMetrics {
+		if _, ok := subset.MetricGroups[timedOut.Type]; ok {
+			subset.TimedOutMetrics = append(subset.TimedOutMetrics, timedOut)
+		}
+	}
+	return &subset
+}
+
+// buildMessage 生成 MIME 邮件，正文为 HTML，完整报告作为附件
+func (e *Email) buildMessage(delivery emailDelivery, n *Notification, attachment []byte) ([]byte, error) {
+	var subject bytes.Buffer
+	if err := e.subject.Execute(&subject, n); err != nil {
+		return nil, fmt.Errorf("executing subject template: %w", err)
+	}
+	var body bytes.Buffer
+	if err := e.body.Execute(&body, n); err != nil {
+		return nil, fmt.Errorf("executing email template: %w", err)
+	}
+
+	var message bytes.Buffer
+	writer := multipart.NewWriter(&message)
+
+	headers := [][2]string{
+		{"From", e.cfg.From},
+		{"To", strings.Join(delivery.to, ", ")},
+		{"Cc", strings.Join(delivery.cc, ", ")},
+		{"Subject", mime.BEncoding.Encode("UTF-8", strings.TrimSpace(subject.String()))},
+		{"Date", time.Now().Format(time.RFC1123Z)},
+		{"Message-ID", messageID(e.cfg.Host)},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/mixed; boundary=" + writer.Boundary()},
+	}
+	for _, header := range headers {
+		if header[1] != "" {
+			fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+		}
+	}
+	message.WriteString("\r\n")
+
+	if err := writeBase64Part(writer, textproto.MIMEHeader{
+		"Content-Type": {"text/html; charset=UTF-8"},
+	}, body.Bytes()); err != nil {
+		return nil, err
+	}
+
+	if attachment != nil {
+		name := filepath.Base(n.ReportPath)
+		contentType, params, err := mime.ParseMediaType(mime.TypeByExtension(filepath.Ext(name)))
+		if err != nil {
+			contentType, params = "application/octet-stream", map[string]string{}
+		}
+		params["name"] = name
+		if err := writeBase64Part(writer, textproto.MIMEHeader{
+			"Content-Type":        {mime.FormatMediaType(contentType, params)},
+			"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": name})},
+		}, attachment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("closing multipart writer: %w", err)
+	}
+	return message.Bytes(), nil
+}
+
+// writeBase64Part 写入 base64 编码的 MIME 段，每行 76 个字符
+func writeBase64Part(writer *multipart.Writer, header textproto.MIMEHeader, content []byte) error {
+	header.Set("Content-Transfer-Encoding", "base64")
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("creating mime part: %w", err)
+	}
+	encoded := base64.StdEncoding.EncodeToString(content)
+	for len(encoded) > 76 {
+		if _, err := part.Write([]byte(encoded[:76] + "\r\n")); err != nil {
+			return err
+		}
+		encoded = encoded[76:]
+	}
+	_, err = part.Write([]byte(encoded + "\r\n"))
+	return err
+}
+
+// messageID 生成邮件的 Message-ID
+func messageID(host string) string {
+	random := make([]byte, 8)
+	rand.Read(random)
+	return fmt.Sprintf("<%d.%s@%s>", time.Now().UnixNano(), hex.EncodeToString(random), host)
+}
+
+// send 连接 SMTP 服务器并发送邮件
+func (e *Email) send(ctx context.Context, recipients []string, message []byte) error {
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("connecting to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	tlsConfig := &tls.Config{
+		ServerName:         e.cfg.Host,
+		InsecureSkipVerify: e.cfg.InsecureSkipVerify,
+	}
+	if e.tlsMode == TLSModeTLS {
+		conn = tls.Client(conn, tlsConfig)
+	}
+
+	client, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("creating smtp client: %w", err)
+	}
+	defer client.Close()
+
+	if e.tlsMode != TLSModeTLS && e.tlsMode != TLSModeNone {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		} else if e.tlsMode == TLSModeStartTLS {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+	}
+
+	if e.cfg.Username != "" {
+		password, err := e.password()
+		if err != nil {
+			return err
+		}
+		if err := client.Auth(smtp.PlainAuth("", e.cfg.Username, password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(e.cfg.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, recipient := range recipients {
+		parsed, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return fmt.Errorf("parsing recipient %q: %w", recipient, err)
+		}
+		if err := client.Rcpt(parsed.Address); err != nil {
+			return fmt.Errorf("smtp rcpt %s: %w", parsed.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(message); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	return client.Quit()
+}
+
+// password 返回 SMTP 密码，配置了 password_file 时每次重新读取
+func (e *Email) password() (string, error) {
+	if e.cfg.PasswordFile == "" {
+		return e.cfg.Password, nil
+	}
+	content, err := os.ReadFile(e.cfg.PasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("reading password_file: %w", err)
+	}
+	return strings.TrimSpace(string(content)), nil
+}
@@ -0,0 +1,213 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+
+	"PromAI/pkg/config"
+	"PromAI/pkg/report"
+)
+
+// Notification 一次通知的内容
+type Notification struct {
+	Summary    Summary
+	Data       *report.ReportData
+	ReportPath string // 报告文件路径，例如 reports/inspection_report_20241227_123810.html
+}
+
+// Notifier 通知渠道
+type Notifier interface {
+	Notify(ctx context.Context, n *Notification) error
+}
+
+// partialNotifier 分多次发送的通知渠道，例如按订阅分组发送的邮件
+// 重试时只发送上次未成功的部分，已发送成功的部分不会重复发送
+type partialNotifier interface {
+	Notifier
+	// NotifyPending 发送 pending 中的部分，pending 为 nil 时发送全部，返回仍未发送成功的部分
+	NotifyPending(ctx context.Context, n *Notification, pending []string) ([]string, error)
+}
+
+// channel 一个已配置的通知渠道及其过滤条件和重试策略
+type channel struct {
+	kind     string
+	settings config.NotifierConfig
+	filter   *vm.Program
+	notifier Notifier
+}
+
+// Dispatcher 在报告生成后向所有通知渠道发送摘要
+type Dispatcher struct {
+	externalURL string
+	topN        int
+	channels    []*channel
+}
+
+// NewDispatcher 根据配置创建所有通知渠道，并校验过滤条件和模板
+func NewDispatcher(cfg config.NotifyConfig) (*Dispatcher, error) {
+	d := &Dispatcher{
+		externalURL: cfg.ExternalURL,
+		topN:        cfg.Limit(),
+	}
+
+	for _, webhook := range cfg.Webhooks {
+		notifier, err := NewWebhook(webhook)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", webhook.Name, err)
+		}
+		if err := d.add("webhook", webhook.NotifierConfig, notifier); err != nil {
+			return nil, err
+		}
+	}
+
+	robots := []struct {
+		kind    string
+		configs []config.RobotConfig
+		create  func(config.RobotConfig) (Notifier, error)
+	}{
+		{"dingtalk", cfg.DingTalk, func(c config.RobotConfig) (Notifier, error) { return NewDingTalk(c) }},
+		{"wecom", cfg.WeCom, func(c config.RobotConfig) (Notifier, error) { return NewWeCom(c) }},
+		{"feishu", cfg.Feishu, func(c config.RobotConfig) (Notifier, error) { return NewFeishu(c) }},
+	}
+	for _, r := range robots {
+		for _, robotConfig := range r.configs {
+			notifier, err := r.create(robotConfig)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", r.kind, robotConfig.Name, err)
+			}
+			if err := d.add(r.kind, robotConfig.NotifierConfig, notifier); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, emailConfig := range cfg.Email {
+		notifier, err := NewEmail(emailConfig, d.topN)
+		if err != nil {
+			return nil, fmt.Errorf("email %s: %w", emailConfig.Name, err)
+		}
+		if err := d.add("email", emailConfig.NotifierConfig, notifier); err != nil {
+			return nil, err
+		}
+	}
+
+	cards := []struct {
+		kind    string
+		configs []config.CardConfig
+		create  func(config.CardConfig) (Notifier, error)
+	}{
+		{"slack", cfg.Slack, func(c config.CardConfig) (Notifier, error) { return NewSlack(c) }},
+		{"teams", cfg.Teams, func(c config.CardConfig) (Notifier, error) { return NewTeams(c) }},
+	}
+	for _, c := range cards {
+		for _, cardConfig := range c.configs {
+			notifier, err := c.create(cardConfig)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", c.kind, cardConfig.Name, err)
+			}
+			if err := d.add(c.kind, cardConfig.NotifierConfig, notifier); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return d, nil
+}
+
+// add 添加通知渠道，编译其过滤条件
+func (d *Dispatcher) add(kind string, settings config.NotifierConfig, notifier Notifier) error {
+	if settings.Name == "" {
+		count := 1
+		for _, ch := range d.channels {
+			if ch.kind == kind {
+				count++
+			}
+		}
+		settings.Name = fmt.Sprintf("%s-%d", kind, count)
+	}
+	ch := &channel{kind: kind, settings: settings, notifier: notifier}
+	if settings.Filter != "" {
+		program, err := expr.Compile(settings.Filter, expr.Env(Summary{}), expr.AsBool())
+		if err != nil {
+			return fmt.Errorf("compiling filter of %s %s: %w", kind, settings.Name, err)
+		}
+		ch.filter = program
+	}
+	d.channels = append(d.channels, ch)
+	return nil
+}
+
+// Dispatch 生成摘要并发送到所有满足过滤条件的通知渠道，等待全部发送结束
+func (d *Dispatcher) Dispatch(ctx context.Context, data *report.ReportData, reportPath string) {
+	if d == nil || len(d.channels) == 0 {
+		return
+	}
+
+	n := &Notification{
+		Summary:    BuildSummary(data, reportPath, d.externalURL, d.topN),
+		Data:       data,
+		ReportPath: reportPath,
+	}
+
+	var wg sync.WaitGroup
+	for _, ch := range d.channels {
+		wg.Add(1)
+		go func(ch *channel) {
+			defer wg.Done()
+			ch.send(ctx, n)
+		}(ch)
+	}
+	wg.Wait()
+}
+
+// send 检查过滤条件并发送通知，失败时按重试策略重试
+func (ch *channel) send(ctx context.Context, n *Notification) {
+	name := ch.kind + "/" + ch.settings.Name
+
+	if ch.filter != nil {
+		output, err := expr.Run(ch.filter, n.Summary)
+		if err != nil {
+			log.Printf("通知 [%s] 过滤条件执行失败: %v", name, err)
+			return
+		}
+		if matched, _ := output.(bool); !matched {
+			log.Printf("通知 [%s] 不满足过滤条件 %q，跳过发送", name, ch.settings.Filter)
+			return
+		}
+	}
+
+	timeout, retries, interval := ch.settings.RetryPolicy()
+	var pending []string // 分多次发送的渠道中尚未发送成功的部分，nil 表示全部
+	for attempt := 0; ; attempt++ {
+		sendCtx, cancel := context.WithTimeout(ctx, timeout)
+		var err error
+		if p, ok := ch.notifier.(partialNotifier); ok {
+			pending, err = p.NotifyPending(sendCtx, n, pending)
+		} else {
+			err = ch.notifier.Notify(sendCtx, n)
+		}
+		cancel()
+		if err == nil {
+			log.Printf("通知 [%s] 发送成功", name)
+			return
+		}
+		if attempt >= retries {
+			log.Printf("通知 [%s] 发送失败，已重试 %d 次: %v", name, attempt, err)
+			return
+		}
+
+		log.Printf("通知 [%s] 发送失败，%v 后重试: %v", name, interval, err)
+		select {
+		case <-time.After(interval):
+		case <-ctx.Done():
+			log.Printf("通知 [%s] 已取消: %v", name, ctx.Err())
+			return
+		}
+		interval *= 2
+	}
+}
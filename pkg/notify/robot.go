@@ -0,0 +1,278 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"PromAI/pkg/config"
+)
+
+// defaultMarkdownTemplate 群机器人默认的 markdown 消息模板
+const defaultMarkdownTemplate = `### 巡检报告：{{.Summary.StatusText}}
+> 时间：{{date "2006-01-02 15:04:05" .Summary.Timestamp}}
+
+**严重 {{.Summary.CriticalCount}}** | 警告 {{.Summary.WarningCount}} | 无数据 {{.Summary.NoDataCount}} | 查询失败 {{.Summary.ErrorCount}} | 共 {{.Summary.TotalCount}} 项
+{{if .Summary.TimedOutCount}}
+查询超时的指标：{{.Summary.TimedOutCount}} 个
+{{end}}
+**分组统计**
+{{range .Summary.Groups}}
+- {{.Type}}：严重 {{.CriticalCount}}，警告 {{.WarningCount}}，共 {{.TotalCount}} 项
+{{- end}}
+{{if .Summary.TopRows}}
+**异常记录**
+{{range .Summary.TopRows}}
+- [{{.StatusText}}] {{.Type}} / {{.Name}}{{if .Labels}}（{{.Labels}}）{{end}}{{if or (eq .Status "critical") (eq .Status "warning")}}：{{printf "%.2f" .Value}}{{.Unit}}，阈值 {{printf "%.2f" .Threshold}}{{.Unit}}{{end}}
+{{- end}}
+{{end}}{{if .Summary.ReportURL}}
+[查看完整报告]({{.Summary.ReportURL}})
+{{end}}`
+
+// wecomMaxContentBytes 企业微信 markdown 消息内容的最大字节数
+const wecomMaxContentBytes = 4096
+
+// robot 群机器人通知的公共部分
+type robot struct {
+	url      string
+	secret   string
+	template *template.Template
+	client   *http.Client
+}
+
+// newRobot 解析机器人配置和消息模板
+func newRobot(cfg config.RobotConfig) (robot, error) {
+	if cfg.URL == "" {
+		return robot{}, fmt.Errorf("url is required")
+	}
+	text := cfg.Template
+	if text == "" {
+		text = defaultMarkdownTemplate
+	}
+	tmpl, err := parseTemplate(cfg.Name, text)
+	if err != nil {
+		return robot{}, err
+	}
+	return robot{
+		url:      cfg.URL,
+		secret:   cfg.Secret,
+		template: tmpl,
+		client:   &http.Client{},
+	}, nil
+}
+
+// render 渲染 markdown 消息内容
+func (r robot) render(n *Notification) (string, error) {
+	var content bytes.Buffer
+	if err := r.template.Execute(&content, n); err != nil {
+		return "", fmt.Errorf("executing template: %w", err)
+	}
+	return content.String(), nil
+}
+
+// post 发送消息并检查机器人返回的错误码
+func (r robot) post(ctx context.Context, target string, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encoding message: %w", err)
+	}
+	content, err := sendRequest(ctx, r.client, http.MethodPost, target, nil, body)
+	if err != nil {
+		return err
+	}
+
+	// 钉钉和企业微信返回 errcode/errmsg，飞书返回 code/msg
+	var result struct {
+		ErrCode *int   `json:"errcode"`
+		ErrMsg  string `json:"errmsg"`
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return fmt.Errorf("parsing response: %w", err)
+	}
+	if result.ErrCode != nil && *result.ErrCode != 0 {
+		return fmt.Errorf("robot error %d: %s", *result.ErrCode, result.ErrMsg)
+	}
+	if result.Code != nil && *result.Code != 0 {
+		return fmt.Errorf("robot error %d: %s", *result.Code, result.Msg)
+	}
+	return nil
+}
+
+// messageTitle 消息标题
+func messageTitle(n *Notification) string {
+	return "巡检报告：" + n.Summary.StatusText
+}
+
+// DingTalk 钉钉群机器人通知
+type DingTalk struct {
+	robot
+}
+
+// NewDingTalk 创建钉钉群机器人通知
+func NewDingTalk(cfg config.RobotConfig) (*DingTalk, error) {
+	r, err := newRobot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &DingTalk{robot: r}, nil
+}
+
+// Notify 发送钉钉 markdown 消息，配置了 secret 时对请求加签
+func (d *DingTalk) Notify(ctx context.Context, n *Notification) error {
+	text, err := d.render(n)
+	if err != nil {
+		return err
+	}
+
+	target := d.url
+	if d.secret != "" {
+		target, err = dingTalkSignedURL(d.url, d.secret, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	return d.post(ctx, target, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"title": messageTitle(n),
+			"text":  text,
+		},
+	})
+}
+
+// dingTalkSignedURL 按钉钉加签规则在地址后附加 timestamp 和 sign 参数
+// sign = base64(HmacSHA256(secret, timestamp + "\n" + secret))，timestamp 为毫秒时间戳
+func dingTalkSignedURL(rawURL, secret string, now time.Time) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("parsing url: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.UnixMilli(), 10)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "\n" + secret))
+
+	query := u.Query()
+	query.Set("timestamp", timestamp)
+	query.Set("sign", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// WeCom 企业微信群机器人通知
+type WeCom struct {
+	robot
+}
+
+// NewWeCom 创建企业微信群机器人通知
+func NewWeCom(cfg config.RobotConfig) (*WeCom, error) {
+	r, err := newRobot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &WeCom{robot: r}, nil
+}
+
+// Notify 发送企业微信 markdown 消息，超过长度限制时截断
+func (w *WeCom) Notify(ctx context.Context, n *Notification) error {
+	text, err := w.render(n)
+	if err != nil {
+		return err
+	}
+
+	return w.post(ctx, w.url, map[string]interface{}{
+		"msgtype": "markdown",
+		"markdown": map[string]string{
+			"content": truncateBytes(text, wecomMaxContentBytes),
+		},
+	})
+}
+
+// truncateBytes 按字节数截断字符串，不截断多字节字符
+func truncateBytes(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for len(s) > 0 && !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+// Feishu 飞书群机器人通知
+type Feishu struct {
+	robot
+}
+
+// NewFeishu 创建飞书群机器人通知
+func NewFeishu(cfg config.RobotConfig) (*Feishu, error) {
+	r, err := newRobot(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Feishu{robot: r}, nil
+}
+
+// Notify 发送飞书消息卡片，卡片标题颜色随整体状态变化，配置了 secret 时对请求加签
+func (f *Feishu) Notify(ctx context.Context, n *Notification) error {
+	text, err := f.render(n)
+	if err != nil {
+		return err
+	}
+
+	message := map[string]interface{}{
+		"msg_type": "interactive",
+		"card": map[string]interface{}{
+			"config": map[string]interface{}{"wide_screen_mode": true},
+			"header": map[string]interface{}{
+				"title":    map[string]string{"tag": "plain_text", "content": messageTitle(n)},
+				"template": feishuHeaderColor(n.Summary.Status),
+			},
+			"elements": []interface{}{
+				map[string]string{"tag": "markdown", "content": text},
+			},
+		},
+	}
+	if f.secret != "" {
+		timestamp, sign := feishuSign(f.secret, time.Now())
+		message["timestamp"] = timestamp
+		message["sign"] = sign
+	}
+
+	return f.post(ctx, f.url, message)
+}
+
+// feishuSign 按飞书加签规则计算签名
+// sign = base64(HmacSHA256(timestamp + "\n" + secret, "")), timestamp 为秒级时间戳
+func feishuSign(secret string, now time.Time) (string, string) {
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(timestamp+"\n"+secret))
+	return timestamp, base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// feishuHeaderColor 根据整体状态返回卡片标题颜色
+func feishuHeaderColor(status string) string {
+	switch status {
+	case "critical", "error":
+		return "red"
+	case "warning":
+		return "orange"
+	case "nodata":
+		return "grey"
+	default:
+		return "green"
+	}
+}
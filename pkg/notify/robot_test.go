@@ -0,0 +1,292 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"PromAI/pkg/config"
+)
+
+// robotRequest 测试服务器收到的请求
+type robotRequest struct {
+	query url.Values
+	body  map[string]interface{}
+}
+
+// newRobotServer 启动模拟群机器人接口的测试服务器，每次请求返回 status 和 response
+func newRobotServer(t *testing.T, status int, response string) (*httptest.Server, *[]robotRequest) {
+	t.Helper()
+	var requests []robotRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if ct := r.Header.Get("Content-Type"); ct != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", ct)
+		}
+		content, _ := io.ReadAll(r.Body)
+		req := robotRequest{query: r.URL.Query()}
+		if err := json.Unmarshal(content, &req.body); err != nil {
+			t.Errorf("request body is not JSON: %v", err)
+		}
+		requests = append(requests, req)
+		w.WriteHeader(status)
+		io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func testNotification() *Notification {
+	return &Notification{Summary: Summary{
+		Timestamp:     time.Date(2024, 12, 27, 12, 38, 10, 0, time.Local),
+		Status:        "critical",
+		StatusText:    "严重",
+		ReportURL:     "http://localhost:8091/reports/inspection_report_20241227_123810.html",
+		CriticalCount: 1,
+		TotalCount:    3,
+		Groups:        []GroupSummary{{Type: "基础资源", CriticalCount: 1, TotalCount: 3}},
+		TopRows: []RowSummary{{
+			Type: "基础资源", Name: "CPU使用率", Labels: "节点=10.0.0.1:9100",
+			Value: 92.5, Threshold: 80, Unit: "%", Status: "critical", StatusText: "严重",
+		}},
+	}}
+}
+
+// field 按路径读取 JSON 对象中的字段
+func field(t *testing.T, v interface{}, path ...string) interface{} {
+	t.Helper()
+	for _, key := range path {
+		object, ok := v.(map[string]interface{})
+		if !ok {
+			t.Fatalf("%s: not an object", strings.Join(path, "."))
+		}
+		v = object[key]
+	}
+	return v
+}
+
+func hmacBase64(key, message string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(message))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestDingTalkNotify(t *testing.T) {
+	server, requests := newRobotServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	d, err := NewDingTalk(config.RobotConfig{URL: server.URL + "/robot/send?access_token=token", Secret: "SECtest"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().UnixMilli()
+	if err := d.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	if len(*requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(*requests))
+	}
+	req := (*requests)[0]
+
+	if got := field(t, req.body, "msgtype"); got != "markdown" {
+		t.Errorf("msgtype = %v, want markdown", got)
+	}
+	if got := field(t, req.body, "markdown", "title"); got != "巡检报告：严重" {
+		t.Errorf("markdown.title = %v", got)
+	}
+	text, _ := field(t, req.body, "markdown", "text").(string)
+	for _, want := range []string{"巡检报告：严重", "CPU使用率", "92.50%", "[查看完整报告](http://localhost:8091/"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("markdown.text does not contain %q:\n%s", want, text)
+		}
+	}
+
+	if got := req.query.Get("access_token"); got != "token" {
+		t.Errorf("access_token = %q, want token", got)
+	}
+	timestamp := req.query.Get("timestamp")
+	ms, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || ms < before || ms > time.Now().UnixMilli() {
+		t.Errorf("timestamp = %q, want current time in milliseconds", timestamp)
+	}
+	if got, want := req.query.Get("sign"), hmacBase64("SECtest", timestamp+"\nSECtest"); got != want {
+		t.Errorf("sign = %q, want %q", got, want)
+	}
+}
+
+func TestDingTalkWithoutSecret(t *testing.T) {
+	server, requests := newRobotServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	d, err := NewDingTalk(config.RobotConfig{URL: server.URL + "/robot/send?access_token=token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	query := (*requests)[0].query
+	if query.Has("timestamp") || query.Has("sign") {
+		t.Errorf("unsigned request has signature parameters: %v", query)
+	}
+}
+
+func TestFeishuNotify(t *testing.T) {
+	server, requests := newRobotServer(t, http.StatusOK, `{"code":0,"msg":"success"}`)
+	f, err := NewFeishu(config.RobotConfig{URL: server.URL, Secret: "feishu-secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := time.Now().Unix()
+	if err := f.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	body := (*requests)[0].body
+
+	if got := field(t, body, "msg_type"); got != "interactive" {
+		t.Errorf("msg_type = %v, want interactive", got)
+	}
+	if got := field(t, body, "card", "header", "template"); got != "red" {
+		t.Errorf("card.header.template = %v, want red", got)
+	}
+	if got := field(t, body, "card", "header", "title", "content"); got != "巡检报告：严重" {
+		t.Errorf("card.header.title.content = %v", got)
+	}
+	elements, _ := field(t, body, "card", "elements").([]interface{})
+	if len(elements) != 1 || field(t, elements[0], "tag") != "markdown" ||
+		!strings.Contains(field(t, elements[0], "content").(string), "CPU使用率") {
+		t.Errorf("card.elements = %v", elements)
+	}
+
+	timestamp, _ := body["timestamp"].(string)
+	sec, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || sec < before || sec > time.Now().Unix() {
+		t.Errorf("timestamp = %q, want current time in seconds", timestamp)
+	}
+	if got, want := body["sign"], hmacBase64(timestamp+"\nfeishu-secret", ""); got != want {
+		t.Errorf("sign = %v, want %q", got, want)
+	}
+}
+
+func TestFeishuHeaderColor(t *testing.T) {
+	for status, want := range map[string]string{
+		"critical": "red",
+		"error":    "red",
+		"warning":  "orange",
+		"nodata":   "grey",
+		"normal":   "green",
+	} {
+		if got := feishuHeaderColor(status); got != want {
+			t.Errorf("feishuHeaderColor(%q) = %q, want %q", status, got, want)
+		}
+	}
+}
+
+func TestWeComNotify(t *testing.T) {
+	server, requests := newRobotServer(t, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`)
+	// 模板输出超过企业微信的长度限制，且截断位置落在多字节字符中间
+	w, err := NewWeCom(config.RobotConfig{URL: server.URL, Template: "#" + strings.Repeat("巡检", 2000)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Notify(context.Background(), testNotification()); err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	req := (*requests)[0]
+
+	if got := field(t, req.body, "msgtype"); got != "markdown" {
+		t.Errorf("msgtype = %v, want markdown", got)
+	}
+	content, _ := field(t, req.body, "markdown", "content").(string)
+	if len(content) > wecomMaxContentBytes || len(content) < wecomMaxContentBytes-utf8.UTFMax {
+		t.Errorf("content is %d bytes, want at most %d", len(content), wecomMaxContentBytes)
+	}
+	if !utf8.ValidString(content) || !strings.HasPrefix(content, "#巡检") {
+		t.Errorf("content was not truncated on a character boundary")
+	}
+	if req.query.Has("sign") {
+		t.Errorf("WeCom request should not be signed")
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	tests := []struct {
+		s    string
+		max  int
+		want string
+	}{
+		{"abc", 5, "abc"},
+		{"abc", 3, "abc"},
+		{"abcdef", 3, "abc"},
+		{"巡检报告", 6, "巡检"},
+		{"巡检报告", 7, "巡检"},
+		{"巡检报告", 8, "巡检"},
+		{"a巡检", 2, "a"},
+		{"巡检", 0, ""},
+	}
+	for _, tt := range tests {
+		if got := truncateBytes(tt.s, tt.max); got != tt.want {
+			t.Errorf("truncateBytes(%q, %d) = %q, want %q", tt.s, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestRobotErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		notifier func(url string) (Notifier, error)
+		status   int
+		response string
+		want     string // 错误信息中应包含的内容，为空表示发送成功
+	}{
+		{"dingtalk errcode", dingTalkNotifier, http.StatusOK, `{"errcode":310000,"errmsg":"sign not match"}`, "310000: sign not match"},
+		{"dingtalk ok", dingTalkNotifier, http.StatusOK, `{"errcode":0,"errmsg":"ok"}`, ""},
+		{"wecom errcode", weComNotifier, http.StatusOK, `{"errcode":93000,"errmsg":"invalid webhook url"}`, "93000: invalid webhook url"},
+		{"feishu code", feishuNotifier, http.StatusOK, `{"code":19021,"msg":"sign match fail or timestamp is not within one hour from current time"}`, "19021"},
+		{"feishu StatusCode", feishuNotifier, http.StatusOK, `{"StatusCode":0,"StatusMessage":"success"}`, ""},
+		{"http status", feishuNotifier, http.StatusBadGateway, `bad gateway`, "502"},
+		{"invalid json", weComNotifier, http.StatusOK, `not json`, "parsing response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newRobotServer(t, tt.status, tt.response)
+			n, err := tt.notifier(server.URL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = n.Notify(context.Background(), testNotification())
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Notify: %v", err)
+			case tt.want != "" && err == nil:
+				t.Errorf("Notify succeeded, want error containing %q", tt.want)
+			case tt.want != "" && !strings.Contains(err.Error(), tt.want):
+				t.Errorf("Notify error %q does not contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func dingTalkNotifier(url string) (Notifier, error) {
+	return NewDingTalk(config.RobotConfig{URL: url, Secret: "secret"})
+}
+
+func weComNotifier(url string) (Notifier, error) {
+	return NewWeCom(config.RobotConfig{URL: url})
+}
+
+func feishuNotifier(url string) (Notifier, error) {
+	return NewFeishu(config.RobotConfig{URL: url, Secret: "secret"})
+}
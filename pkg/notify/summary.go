@@ -0,0 +1,137 @@
+package notify
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"PromAI/pkg/report"
+)
+
+// GroupSummary 某个指标类型的告警统计
+type GroupSummary struct {
+	Type          string `json:"type"`
+	CriticalCount int    `json:"critical_count"`
+	WarningCount  int    `json:"warning_count"`
+	NoDataCount   int    `json:"nodata_count"`
+	ErrorCount    int    `json:"error_count"`
+	TotalCount    int    `json:"total_count"`
+}
+
+// RowSummary 摘要中列出的一条异常记录
+type RowSummary struct {
+	Type       string  `json:"type"`
+	Name       string  `json:"name"`
+	Labels     string  `json:"labels"` // 标签别名和值，例如 "节点=10.0.0.1:9100, 挂载点=/"
+	Value      float64 `json:"value"`
+	Threshold  float64 `json:"threshold"`
+	Unit       string  `json:"unit"`
+	Status     string  `json:"status"`
+	StatusText string  `json:"status_text"`
+	Datasource string  `json:"datasource"`
+}
+
+// Summary 巡检结果摘要，用于通知内容和过滤条件
+type Summary struct {
+	Timestamp     time.Time      `json:"timestamp"`
+	Status        string         `json:"status"` // 整体状态，取所有记录中最严重的状态
+	StatusText    string         `json:"status_text"`
+	ReportURL     string         `json:"report_url"`
+	CriticalCount int            `json:"critical_count"`
+	WarningCount  int            `json:"warning_count"`
+	NoDataCount   int            `json:"nodata_count"`
+	ErrorCount    int            `json:"error_count"`
+	TotalCount    int            `json:"total_count"`
+	TimedOutCount int            `json:"timed_out_count"` // 查询超时的指标数量
+	Groups        []GroupSummary `json:"groups"`
+	TopRows       []RowSummary   `json:"top_rows"` // 最严重的若干条记录，严重在前
+}
+
+// BuildSummary 根据报告数据生成摘要
+func BuildSummary(data *report.ReportData, reportPath, externalURL string, topN int) Summary {
+	summary := Summary{
+		Timestamp:     data.Timestamp,
+		Status:        "normal",
+		ReportURL:     reportURL(reportPath, externalURL),
+		TimedOutCount: len(data.TimedOutMetrics),
+		Groups:        []GroupSummary{},
+		TopRows:       []RowSummary{},
+	}
+
+	types := make([]string, 0, len(data.MetricGroups))
+	for groupType := range data.MetricGroups {
+		types = append(types, groupType)
+	}
+	sort.Strings(types)
+
+	var rows []RowSummary
+	for _, groupType := range types {
+		group := data.MetricGroups[groupType]
+		groupSummary := GroupSummary{Type: groupType}
+
+		names := make([]string, 0, len(group.MetricsByName))
+		for name := range group.MetricsByName {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, metric := range group.MetricsByName[name] {
+				groupSummary.TotalCount++
+				switch metric.Status {
+				case "critical":
+					groupSummary.CriticalCount++
+				case "warning":
+					groupSummary.WarningCount++
+				case "nodata":
+					groupSummary.NoDataCount++
+				case "error":
+					groupSummary.ErrorCount++
+				default:
+					continue
+				}
+				if report.StatusSeverity(metric.Status) > report.StatusSeverity(summary.Status) {
+					summary.Status = metric.Status
+				}
+				rows = append(rows, RowSummary{
+					Type:       groupType,
+					Name:       metric.Name,
+					Labels:     report.FormatLabels(metric.Labels),
+					Value:      metric.Value,
+					Threshold:  metric.Threshold,
+					Unit:       metric.Unit,
+					Status:     metric.Status,
+					StatusText: metric.StatusText,
+					Datasource: metric.Datasource,
+				})
+			}
+		}
+
+		summary.CriticalCount += groupSummary.CriticalCount
+		summary.WarningCount += groupSummary.WarningCount
+		summary.NoDataCount += groupSummary.NoDataCount
+		summary.ErrorCount += groupSummary.ErrorCount
+		summary.TotalCount += groupSummary.TotalCount
+		summary.Groups = append(summary.Groups, groupSummary)
+	}
+	summary.StatusText = report.GetStatusText(summary.Status)
+
+	// 按严重程度排序，同一状态保持指标类型和名称的顺序
+	sort.SliceStable(rows, func(i, j int) bool {
+		return report.StatusSeverity(rows[i].Status) > report.StatusSeverity(rows[j].Status)
+	})
+	if len(rows) > topN {
+		rows = rows[:topN]
+	}
+	summary.TopRows = append(summary.TopRows, rows...)
+
+	return summary
+}
+
+// reportURL 返回报告的访问地址，未配置 external_url 时返回相对路径
+func reportURL(reportPath, externalURL string) string {
+	if reportPath == "" {
+		return ""
+	}
+	return strings.TrimSuffix(externalURL, "/") + "/" + strings.TrimPrefix(reportPath, "/")
+}
@@ -0,0 +1,109 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+
+	"PromAI/pkg/config"
+	"PromAI/pkg/report"
+)
+
+// templateFuncs 通知模板可以使用的函数
+var templateFuncs = template.FuncMap{
+	// json 将值编码为 JSON，用于在模板中嵌入字符串或对象
+	"json": func(v interface{}) (string, error) {
+		content, err := json.Marshal(v)
+		return string(content), err
+	},
+	"date": report.FormatDate,
+}
+
+// parseTemplate 解析通知模板
+func parseTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parsing template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// Webhook 通用 JSON Webhook 通知
+type Webhook struct {
+	url      string
+	method   string
+	headers  map[string]string
+	template *template.Template
+	client   *http.Client
+}
+
+// NewWebhook 创建 Webhook 通知
+func NewWebhook(cfg config.WebhookConfig) (*Webhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	w := &Webhook{
+		url:     cfg.URL,
+		method:  strings.ToUpper(cfg.Method),
+		headers: cfg.Headers,
+		client:  &http.Client{},
+	}
+	if w.method == "" {
+		w.method = http.MethodPost
+	}
+	if cfg.Template != "" {
+		tmpl, err := parseTemplate(cfg.Name, cfg.Template)
+		if err != nil {
+			return nil, err
+		}
+		w.template = tmpl
+	}
+	return w, nil
+}
+
+// Notify 发送 Webhook 请求，未配置模板时发送 JSON 格式的摘要
+func (w *Webhook) Notify(ctx context.Context, n *Notification) error {
+	var body bytes.Buffer
+	if w.template != nil {
+		if err := w.template.Execute(&body, n); err != nil {
+			return fmt.Errorf("executing template: %w", err)
+		}
+	} else if err := json.NewEncoder(&body).Encode(n.Summary); err != nil {
+		return fmt.Errorf("encoding summary: %w", err)
+	}
+
+	_, err := sendRequest(ctx, w.client, w.method, w.url, w.headers, body.Bytes())
+	return err
+}
+
+// sendRequest 发送 JSON 请求，响应状态码不是 2xx 时返回错误，返回响应内容
+func sendRequest(ctx context.Context, client *http.Client, method, url string, headers map[string]string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return content, fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(content)))
+	}
+	return content, nil
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/prometheus/client_golang/api"
 	v1 "github.com/prometheus/client_golang/api/prometheus/v1"
+
+	"PromAI/pkg/config"
 )
 
 // Client 封装 Prometheus 客户端
@@ -13,9 +15,15 @@ type Client struct {
 }
 
 // NewClient 创建新的 Prometheus 客户端
-func NewClient(url string) (*Client, error) {
+func NewClient(url string, httpConfig config.HTTPConfig) (*Client, error) {
+	roundTripper, err := NewRoundTripper(httpConfig)
+	if err != nil {
+		return nil, fmt.Errorf("creating round tripper: %w", err)
+	}
+
 	client, err := api.NewClient(api.Config{
-		Address: url,
+		Address:      url,
+		RoundTripper: roundTripper,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating prometheus client: %w", err)
@@ -0,0 +1,134 @@
+package prometheus
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/api"
+
+	"PromAI/pkg/config"
+)
+
+// NewRoundTripper 根据认证、TLS 和代理配置创建 http.RoundTripper
+func NewRoundTripper(cfg config.HTTPConfig) (http.RoundTripper, error) {
+	if cfg.BearerToken != "" && cfg.BearerTokenFile != "" {
+		return nil, fmt.Errorf("bearer_token and bearer_token_file are mutually exclusive")
+	}
+	if cfg.BasicAuth != nil && (cfg.BearerToken != "" || cfg.BearerTokenFile != "") {
+		return nil, fmt.Errorf("basic_auth and bearer token are mutually exclusive")
+	}
+
+	transport := api.DefaultRoundTripper.(*http.Transport).Clone()
+
+	tlsConfig, err := newTLSConfig(cfg.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing proxy_url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	return &authRoundTripper{
+		config: cfg,
+		next:   transport,
+	}, nil
+}
+
+// newTLSConfig 创建 TLS 配置
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+	}
+
+	if cfg.CAFile != "" {
+		ca, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading ca_file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates found in ca_file %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if (cfg.CertFile == "") != (cfg.KeyFile == "") {
+		return nil, fmt.Errorf("cert_file and key_file must be set together")
+	}
+	if cfg.CertFile != "" {
+		// 启动时检查一次证书，之后每次握手重新加载以支持证书轮换
+		if _, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("loading client certificate: %w", err)
+		}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("loading client certificate: %w", err)
+			}
+			return &cert, nil
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+// authRoundTripper 为每个请求添加认证信息和自定义请求头
+type authRoundTripper struct {
+	config config.HTTPConfig
+	next   http.RoundTripper
+}
+
+func (rt *authRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+
+	for name, value := range rt.config.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if auth := rt.config.BasicAuth; auth != nil {
+		password := auth.Password
+		if auth.PasswordFile != "" {
+			data, err := os.ReadFile(auth.PasswordFile)
+			if err != nil {
+				closeBody(req)
+				return nil, fmt.Errorf("reading password_file: %w", err)
+			}
+			password = strings.TrimSpace(string(data))
+		}
+		req.SetBasicAuth(auth.Username, password)
+	}
+
+	token := rt.config.BearerToken
+	if rt.config.BearerTokenFile != "" {
+		data, err := os.ReadFile(rt.config.BearerTokenFile)
+		if err != nil {
+			closeBody(req)
+			return nil, fmt.Errorf("reading bearer_token_file: %w", err)
+		}
+		token = strings.TrimSpace(string(data))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return rt.next.RoundTrip(req)
+}
+
+// closeBody 关闭请求体，RoundTrip 出错返回前必须关闭，即使未发送请求
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,231 @@
+package report
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReportDir 报告文件保存目录
+var ReportDir = "reports"
+
+const (
+	reportPrefix    = "inspection_report_"
+	reportIDLayout  = "20060102_150405"
+	metaSuffix      = ".meta.json"
+	LatestReportID  = "latest" // 最新报告的别名
+	reportURLPrefix = "/reports/"
+)
+
+// ErrReportNotFound 报告不存在
+var ErrReportNotFound = errors.New("report not found")
+
+var reportIDPattern = regexp.MustCompile(`^\d{8}_\d{6}$`)
+
+// StatusCounts 报告中各状态的记录数量
+type StatusCounts struct {
+	Normal   int `json:"normal"`
+	Warning  int `json:"warning"`
+	Critical int `json:"critical"`
+	NoData   int `json:"nodata"`
+	Error    int `json:"error"`
+	Total    int `json:"total"`
+}
+
+// ReportMeta 报告元数据，由 GenerateReport 写入报告旁的 .meta.json 文件
+type ReportMeta struct {
+	ID          string       `json:"id"` // 报告时间戳，例如 20241227_123810
+	Timestamp   time.Time    `json:"timestamp"`
+	File        string       `json:"file"`   // 报告文件名
+	Format      string       `json:"format"` // 报告格式，由文件扩展名得到，例如 html、pdf
+	URL         string       `json:"url"`    // 报告访问地址
+	Size        int64        `json:"size"`   // 报告文件大小（字节）
+	Counts      StatusCounts `json:"counts"`
+	Datasources []string     `json:"datasources,omitempty"`
+	HasMeta     bool         `json:"has_meta"` // 是否有元数据文件，旧报告没有元数据时状态数量为空
+}
+
+// reportID 由报告生成时间得到报告 ID
+func reportID(t time.Time) string {
+	return t.Format(reportIDLayout)
+}
+
+// createReportFile 创建新报告文件，返回报告 ID 和已打开的文件，ext 为文件扩展名
+// 文件以 O_EXCL 创建，同一秒内已有报告（包括其他格式的报告和元数据文件）时顺延一秒，
+// 同时生成的多份报告不会互相覆盖，每个 ID 只对应一份报告和一个元数据文件
+func createReportFile(ext string) (string, *os.File, error) {
+	t := time.Now()
+	for ; ; t = t.Add(time.Second) {
+		id := reportID(t)
+		filename := filepath.Join(ReportDir, reportPrefix+id+"."+ext)
+		file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		}
+		if err != nil {
+			return "", nil, fmt.Errorf("creating output file: %w", err)
+		}
+		// 其他格式的报告可能同时使用了这个 ID
+		if matches, _ := filepath.Glob(filepath.Join(ReportDir, reportPrefix+id+".*")); len(matches) > 1 {
+			file.Close()
+			os.Remove(filename)
+			continue
+		}
+		return id, file, nil
+	}
+}
+
+// countStatuses 统计报告中各状态的记录数量
+func countStatuses(data ReportData) StatusCounts {
+	var counts StatusCounts
+	for _, group := range data.MetricGroups {
+		for _, metrics := range group.MetricsByName {
+			for _, metric := range metrics {
+				counts.Total++
+				switch metric.Status {
+				case "critical":
+					counts.Critical++
+				case "warning":
+					counts.Warning++
+				case "nodata":
+					counts.NoData++
+				case "error":
+					counts.Error++
+				default:
+					counts.Normal++
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// writeMeta 写入报告的元数据文件
+func writeMeta(reportPath string, id string, data ReportData) error {
+	info, err := os.Stat(reportPath)
+	if err != nil {
+		return fmt.Errorf("stat report file: %w", err)
+	}
+
+	meta := ReportMeta{
+		ID:          id,
+		Timestamp:   data.Timestamp,
+		File:        filepath.Base(reportPath),
+		Size:        info.Size(),
+		Counts:      countStatuses(data),
+		Datasources: data.Datasources,
+		HasMeta:     true,
+	}
+	content, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding report meta: %w", err)
+	}
+	if err := os.WriteFile(metaPath(id), content, 0644); err != nil {
+		return fmt.Errorf("writing report meta: %w", err)
+	}
+	return nil
+}
+
+// metaPath 返回报告元数据文件路径
+func metaPath(id string) string {
+	return filepath.Join(ReportDir, reportPrefix+id+metaSuffix)
+}
+
+// readMeta 读取报告元数据，没有元数据文件时根据报告文件推断
+func readMeta(id, file string) (ReportMeta, error) {
+	meta := ReportMeta{ID: id, File: file}
+	if content, err := os.ReadFile(metaPath(id)); err == nil {
+		if err := json.Unmarshal(content, &meta); err != nil {
+			return meta, fmt.Errorf("parsing report meta %s: %w", id, err)
+		}
+		meta.HasMeta = true
+	} else if !os.IsNotExist(err) {
+		return meta, fmt.Errorf("reading report meta %s: %w", id, err)
+	}
+
+	info, err := os.Stat(filepath.Join(ReportDir, meta.File))
+	if err != nil {
+		return meta, err
+	}
+	meta.Size = info.Size()
+	if meta.Timestamp.IsZero() {
+		if ts, err := time.ParseInLocation(reportIDLayout, id, time.Local); err == nil {
+			meta.Timestamp = ts
+		} else {
+			meta.Timestamp = info.ModTime()
+		}
+	}
+	meta.URL = reportURLPrefix + meta.File
+	meta.Format = strings.TrimPrefix(filepath.Ext(meta.File), ".")
+	return meta, nil
+}
+
+// ListReports 列出所有报告，按时间从新到旧排序
+func ListReports() ([]ReportMeta, error) {
+	entries, err := os.ReadDir(ReportDir)
+	if os.IsNotExist(err) {
+		return []ReportMeta{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading report dir: %w", err)
+	}
+
+	reports := make([]ReportMeta, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, reportPrefix) || strings.HasSuffix(name, metaSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(strings.TrimPrefix(name, reportPrefix), filepath.Ext(name))
+		if !reportIDPattern.MatchString(id) {
+			continue
+		}
+		meta, err := readMeta(id, name)
+		if err != nil {
+			// 单个报告的元数据损坏或文件读取失败时跳过，不影响其他报告
+			log.Printf("读取报告 %s 失败，已跳过: %v", name, err)
+			continue
+		}
+		reports = append(reports, meta)
+	}
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].ID > reports[j].ID
+	})
+	return reports, nil
+}
+
+// GetReport 获取指定 ID 的报告元数据，ID 为 latest 时返回最新的报告
+func GetReport(id string) (*ReportMeta, error) {
+	if id != LatestReportID && !reportIDPattern.MatchString(id) {
+		return nil, ErrReportNotFound
+	}
+
+	reports, err := ListReports()
+	if err != nil {
+		return nil, err
+	}
+	for i := range reports {
+		if id == LatestReportID || reports[i].ID == id {
+			return &reports[i], nil
+		}
+	}
+	return nil, ErrReportNotFound
+}
+
+// DeleteReport 删除指定 ID 的报告及其元数据，ID 为 latest 时删除最新的报告
+func DeleteReport(id string) error {
+	meta, err := GetReport(id)
+	if err != nil {
+		return err
+	}
+	return removeReport(*meta)
+}
@@ -0,0 +1,43 @@
+package report
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestCreateReportFileConcurrent(t *testing.T) {
+	defer func(dir string) { ReportDir = dir }(ReportDir)
+	ReportDir = t.TempDir()
+
+	const n = 8
+	ids := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ext := FormatHTML
+			if i%2 == 1 {
+				ext = FormatPDF
+			}
+			id, file, err := createReportFile(ext)
+			if err == nil {
+				file.Close()
+			}
+			ids[i], errs[i] = id, err
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool, n)
+	for i := 0; i < n; i++ {
+		if errs[i] != nil {
+			t.Fatalf("createReportFile: %v", errs[i])
+		}
+		if seen[ids[i]] {
+			t.Errorf("report ID %s used more than once", ids[i])
+		}
+		seen[ids[i]] = true
+	}
+}
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// utf8BOM 写在 CSV 文件开头，使 Excel 以 UTF-8 编码打开中文内容
+const utf8BOM = "\xEF\xBB\xBF"
+
+// csvRenderer 生成 CSV 格式的巡检数据，所有指标平铺为一张表，每条记录一行
+type csvRenderer struct{}
+
+func (csvRenderer) Format() string { return FormatCSV }
+
+func (csvRenderer) Render(out io.Writer, data *ReportData) error {
+	types := sortedGroupTypes(*data)
+	groups := make([]*MetricGroup, 0, len(types))
+	for _, groupType := range types {
+		groups = append(groups, data.MetricGroups[groupType])
+	}
+	aliases := labelAliases(groups...)
+	showDatasource := data.ShowDatasource()
+
+	header := []string{"指标类型", "指标名称"}
+	if showDatasource {
+		header = append(header, data.DatasourceTitle())
+	}
+	header = append(header, aliases...)
+	header = append(header, "当前值", "单位", "阈值", "状态", "检查时间")
+
+	if _, err := io.WriteString(out, utf8BOM); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	w := csv.NewWriter(out)
+	if err := w.Write(header); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	for _, group := range groups {
+		for _, name := range sortedMetricNames(group) {
+			for _, metric := range group.MetricsByName[name] {
+				record := []string{group.Type, metric.Name}
+				if showDatasource {
+					record = append(record, metric.Datasource)
+				}
+				for _, alias := range aliases {
+					record = append(record, labelByAlias(metric, alias))
+				}
+				value := ""
+				if metric.HasValue() {
+					value = strconv.FormatFloat(metric.Value, 'f', -1, 64)
+				}
+				record = append(record, value, metric.Unit, thresholdText(metric), metric.StatusText,
+					metric.Timestamp.Format("2006-01-02 15:04:05"))
+				if err := w.Write(record); err != nil {
+					return fmt.Errorf("writing csv: %w", err)
+				}
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"PromAI/templates"
+)
+
+// TemplateFuncs 报告模板可以使用的函数，内置模板和自定义模板共用，HTML 和文本模板均可使用
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		// 格式化
+		"date":           FormatDate,
+		"now":            time.Now,
+		"formatFloat":    formatFloat,
+		"formatBytes":    FormatBytes,
+		"formatDuration": formatDuration,
+		"formatPercent":  func(v float64) string { return formatFloat(2, v) + "%" },
+		"formatRatio":    func(v float64) string { return formatFloat(2, v*100) + "%" },
+		"formatValue":    valueText,
+		"formatLabels":   FormatLabels,
+		"threshold":      thresholdText,
+
+		// 状态
+		"statusText":     GetStatusText,
+		"statusColor":    StatusColor,
+		"statusBg":       StatusBackground,
+		"statusSeverity": StatusSeverity,
+
+		// 标签
+		"label":        labelByName,
+		"labelAliases": metricAliases,
+
+		// 排序和筛选，不修改报告数据
+		"groupTypes":   sortedGroupTypes,
+		"metricNames":  sortedMetricNames,
+		"allMetrics":   allMetrics,
+		"sortByValue":  sortByValue,
+		"sortByStatus": sortByStatus,
+		"sortByLabel":  sortByLabel,
+		"filterStatus": filterStatus,
+		"abnormal":     abnormalMetrics,
+		"top":          topMetrics,
+		"statusCounts": countStatuses,
+
+		// 其他
+		"join":    strings.Join,
+		"upper":   strings.ToUpper,
+		"lower":   strings.ToLower,
+		"toJSON":  toJSON,
+		"chartJS": templates.ChartJS,
+	}
+}
+
+// FormatDate 按 layout 格式化时间，报告、看板和通知模板中的 date 函数共用
+func FormatDate(layout string, t time.Time) string {
+	return t.Format(layout)
+}
+
+// formatFloat 保留指定位数的小数
+func formatFloat(decimals int, v float64) string {
+	return fmt.Sprintf("%.*f", decimals, v)
+}
+
+// FormatBytes 将字节数格式化为易读的大小，例如 1.50 GiB
+func FormatBytes(v float64) string {
+	const unit = 1024
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for math.Abs(v) >= unit && i < len(units)-1 {
+		v /= unit
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", v, units[i])
+	}
+	return fmt.Sprintf("%.2f %s", v, units[i])
+}
+
+// formatDuration 将秒数格式化为易读的时长，例如 3d4h、2h5m、1m30s
+func formatDuration(seconds float64) string {
+	if seconds < 0 {
+		return "-" + formatDuration(-seconds)
+	}
+	if seconds > 0 && seconds < 1 {
+		return fmt.Sprintf("%.0fms", seconds*1000)
+	}
+	d := time.Duration(seconds) * time.Second
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	secs := int(d.Seconds()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd%dh", days, hours)
+	case hours > 0:
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	case minutes > 0:
+		return fmt.Sprintf("%dm%ds", minutes, secs)
+	default:
+		return fmt.Sprintf("%ds", secs)
+	}
+}
+
+// StatusColor 状态的文字颜色，报告、邮件等各种输出共用
+func StatusColor(status string) string {
+	switch status {
+	case "critical", "error":
+		return "#ff4d4f"
+	case "warning":
+		return "#faad14"
+	case "nodata":
+		return "#8c8c8c"
+	default:
+		return "#52c41a"
+	}
+}
+
+// StatusBackground 状态的背景色，报告、邮件等各种输出共用
+func StatusBackground(status string) string {
+	switch status {
+	case "critical", "error":
+		return "#fff1f0"
+	case "warning":
+		return "#fffbe6"
+	case "nodata":
+		return "#f5f5f5"
+	default:
+		return "#ffffff"
+	}
+}
+
+// labelByName 按原始标签名或别名返回标签值，不存在时为空
+func labelByName(name string, metric MetricData) string {
+	for _, label := range metric.Labels {
+		if label.Name == name || label.Alias == name {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// allMetrics 按指标类型和名称的顺序返回报告中的所有记录
+func allMetrics(data ReportData) []MetricData {
+	var metrics []MetricData
+	for _, groupType := range sortedGroupTypes(data) {
+		group := data.MetricGroups[groupType]
+		for _, name := range sortedMetricNames(group) {
+			metrics = append(metrics, group.MetricsByName[name]...)
+		}
+	}
+	return metrics
+}
+
+// sortMetrics 复制记录并稳定排序
+func sortMetrics(metrics []MetricData, less func(a, b MetricData) bool) []MetricData {
+	sorted := append([]MetricData(nil), metrics...)
+	sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+	return sorted
+}
+
+// sortByValue 按当前值从大到小排序，没有值的记录排在最后
+func sortByValue(metrics []MetricData) []MetricData {
+	return sortMetrics(metrics, func(a, b MetricData) bool {
+		if a.HasValue() != b.HasValue() {
+			return a.HasValue()
+		}
+		return a.Value > b.Value
+	})
+}
+
+// sortByStatus 按状态从严重到正常排序
+func sortByStatus(metrics []MetricData) []MetricData {
+	return sortMetrics(metrics, func(a, b MetricData) bool {
+		return statusSeverity[a.Status] > statusSeverity[b.Status]
+	})
+}
+
+// sortByLabel 按指定标签的值排序，标签可以是原始标签名或别名
+func sortByLabel(name string, metrics []MetricData) []MetricData {
+	return sortMetrics(metrics, func(a, b MetricData) bool {
+		return labelByName(name, a) < labelByName(name, b)
+	})
+}
+
+// filterStatus 返回指定状态的记录
+func filterStatus(status string, metrics []MetricData) []MetricData {
+	var filtered []MetricData
+	for _, metric := range metrics {
+		if metric.Status == status {
+			filtered = append(filtered, metric)
+		}
+	}
+	return filtered
+}
+
+// abnormalMetrics 返回非正常状态的记录，从严重到轻微排序
+func abnormalMetrics(metrics []MetricData) []MetricData {
+	var filtered []MetricData
+	for _, metric := range metrics {
+		if statusSeverity[metric.Status] > 0 {
+			filtered = append(filtered, metric)
+		}
+	}
+	return sortByStatus(filtered)
+}
+
+// topMetrics 返回前 n 条记录
+func topMetrics(n int, metrics []MetricData) []MetricData {
+	if n < len(metrics) {
+		return metrics[:n]
+	}
+	return metrics
+}
+
+// toJSON 将任意值编码为 JSON，在 HTML 模板的 script 中使用时不会被再次转义
+func toJSON(v interface{}) (template.JS, error) {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return template.JS(content), nil
+}
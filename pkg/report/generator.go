@@ -6,47 +6,129 @@ import (
 	"html/template"
 	"log"
 	"math"
-	"os"
 	"sort"
 	"time"
 )
 
 type LabelData struct {
-	Name  string // 原始标签名
-	Alias string // 显示的别名
-	Value string // 标签值
+	Name  string `json:"name"`  // 原始标签名
+	Alias string `json:"alias"` // 显示的别名
+	Value string `json:"value"` // 标签值
 }
 type GroupStats struct {
-	MaxValue      float64
-	MinValue      float64
-	Average       float64
-	AlertCount    int // 告警数量
-	CriticalCount int // 严重告警数量
-	WarningCount  int // 警告数量
-	TotalCount    int // 总指标数
+	MaxValue      float64 `json:"max_value"`
+	MinValue      float64 `json:"min_value"`
+	Average       float64 `json:"average"`
+	AlertCount    int     `json:"alert_count"`    // 告警数量
+	CriticalCount int     `json:"critical_count"` // 严重告警数量
+	WarningCount  int     `json:"warning_count"`  // 警告数量
+	NoDataCount   int     `json:"nodata_count"`   // 查询结果为空的数量
+	ErrorCount    int     `json:"error_count"`    // 查询失败的数量
+	TotalCount    int     `json:"total_count"`    // 总指标数
 }
 type MetricData struct {
-	Instance    string
-	Name        string
-	Description string
-	Value       float64
-	Threshold   float64
-	Unit        string
-	Status      string
-	StatusText  string
-	Timestamp   time.Time
-	Labels      []LabelData // 改用结构化的标签数据
+	Instance      string      `json:"instance,omitempty"`
+	Name          string      `json:"name"`
+	Description   string      `json:"description"`
+	Value         float64     `json:"value"`
+	Threshold     float64     `json:"threshold"`
+	ThresholdDesc string      `json:"threshold_desc,omitempty"` // 区间和分段阈值的描述，单值阈值为空
+	Unit          string      `json:"unit"`
+	Status        string      `json:"status"`
+	StatusText    string      `json:"status_text"`
+	Timestamp     time.Time   `json:"timestamp"`
+	Labels        []LabelData `json:"labels"`             // 改用结构化的标签数据
+	Query         string      `json:"query"`              // 执行的 PromQL
+	Datasource    string      `json:"datasource"`         // 数据来源的 Prometheus 数据源名称
+	Override      string      `json:"override,omitempty"` // 命中的阈值覆盖规则名称，未命中时为空
+	Error         string      `json:"error,omitempty"`    // 状态为 nodata 或 error 时的原因
+}
+
+// HasValue 是否为有效的采样值，nodata 和 error 状态没有值
+func (m MetricData) HasValue() bool {
+	return m.Status != "nodata" && m.Status != "error"
+}
+
+// TrendSeries 趋势图中的一条时间序列
+type TrendSeries struct {
+	Name   string     `json:"name"`   // 序列名称，由配置的标签值拼接而成
+	Values []*float64 `json:"values"` // 与 TrendData.Timestamps 一一对应，缺失的采样点为 nil
+}
+
+// TrendThreshold 趋势图中的阈值线
+type TrendThreshold struct {
+	Name  string  `json:"name"`
+	Value float64 `json:"value"`
+}
+
+// TrendData 单个指标的趋势数据
+type TrendData struct {
+	Timestamps []time.Time      `json:"timestamps"`
+	Series     []TrendSeries    `json:"series"`
+	Thresholds []TrendThreshold `json:"thresholds"` // 阈值线
+	Unit       string           `json:"unit"`
+	Chart      template.JS      `json:"-"` // Chart.js 数据，由 GenerateReport 生成
 }
 
 type MetricGroup struct {
-	Type          string
-	MetricsByName map[string][]MetricData
-	Stats         GroupStats // 替换原来的 Average
+	Type          string                  `json:"type"`
+	MetricsByName map[string][]MetricData `json:"metrics_by_name"`
+	TrendsByName  map[string]*TrendData   `json:"trends_by_name"` // 按指标名称存储的趋势数据
+	Stats         GroupStats              `json:"stats"`          // 替换原来的 Average
+}
+
+// TimedOutMetric 查询超时的指标
+type TimedOutMetric struct {
+	Type       string        `json:"type"`       // 指标类型
+	Name       string        `json:"name"`       // 指标名称
+	Datasource string        `json:"datasource"` // 数据源名称
+	Query      string        `json:"query"`      // 超时的 PromQL
+	Timeout    time.Duration `json:"timeout_ns"` // 超时时间
 }
+
 type ReportData struct {
-	Timestamp    time.Time
-	MetricGroups map[string]*MetricGroup
-	ChartData    map[string]template.JS
+	Timestamp       time.Time               `json:"timestamp"`
+	MetricGroups    map[string]*MetricGroup `json:"metric_groups"`
+	ChartData       map[string]template.JS  `json:"-"`
+	TimedOutMetrics []TimedOutMetric        `json:"timed_out_metrics"`         // 查询超时的指标
+	Datasources     []string                `json:"datasources"`               // 本次报告涉及的数据源名称
+	Fleet           bool                    `json:"fleet"`                     // 是否为多集群巡检
+	ClusterSummary  *ClusterSummary         `json:"cluster_summary,omitempty"` // 集群 × 指标类型的告警汇总，涉及多个数据源时由 GenerateReport 生成
+}
+
+// ShowDatasource 报告中是否需要显示数据源列
+func (d ReportData) ShowDatasource() bool {
+	return d.Fleet || len(d.Datasources) > 1
+}
+
+// DatasourceTitle 数据源列的标题
+func (d ReportData) DatasourceTitle() string {
+	if d.Fleet {
+		return "集群"
+	}
+	return "数据源"
+}
+
+// ClusterCell 某个集群在某个指标类型下的告警统计
+type ClusterCell struct {
+	CriticalCount int `json:"critical_count"`
+	WarningCount  int `json:"warning_count"`
+	NoDataCount   int `json:"nodata_count"`
+	ErrorCount    int `json:"error_count"`
+	TotalCount    int `json:"total_count"`
+}
+
+// ClusterSummaryRow 汇总矩阵中的一行，Cells 与 ClusterSummary.Types 一一对应
+type ClusterSummaryRow struct {
+	Cluster string        `json:"cluster"`
+	Cells   []ClusterCell `json:"cells"`
+	Total   ClusterCell   `json:"total"`
+}
+
+// ClusterSummary 集群 × 指标类型的告警汇总矩阵
+type ClusterSummary struct {
+	Types []string            `json:"types"`
+	Rows  []ClusterSummaryRow `json:"rows"`
 }
 
 func GetStatusText(status string) string {
@@ -55,24 +137,37 @@ func GetStatusText(status string) string {
 		return "严重"
 	case "warning":
 		return "警告"
+	case "nodata":
+		return "无数据"
+	case "error":
+		return "查询失败"
 	default:
 		return "正常"
 	}
 }
 
-func GenerateReport(data ReportData) (string, error) {
+// PrepareReport 计算分组统计、集群汇总和图表数据，GenerateReport 和 JSON 接口共用
+func PrepareReport(data *ReportData) error {
+	if data.ChartData == nil {
+		data.ChartData = make(map[string]template.JS)
+	}
+
 	// 计算每个组的统计信息
 	for _, group := range data.MetricGroups {
 		stats := GroupStats{
 			MinValue: math.MaxFloat64,
 		}
 
+		valueCount := 0
 		for _, metrics := range group.MetricsByName {
 			for _, metric := range metrics {
-				// 更新最大最小值
-				stats.MaxValue = math.Max(stats.MaxValue, metric.Value)
-				stats.MinValue = math.Min(stats.MinValue, metric.Value)
 				stats.TotalCount++
+				if metric.HasValue() {
+					// 更新最大最小值
+					stats.MaxValue = math.Max(stats.MaxValue, metric.Value)
+					stats.MinValue = math.Min(stats.MinValue, metric.Value)
+					valueCount++
+				}
 
 				// 累加值用于计算平均值
 				// stats.Average += metric.Value
@@ -85,9 +180,16 @@ func GenerateReport(data ReportData) (string, error) {
 				case "critical":
 					stats.CriticalCount++
 					stats.AlertCount++
+				case "nodata":
+					stats.NoDataCount++
+				case "error":
+					stats.ErrorCount++
 				}
 			}
 		}
+		if valueCount == 0 {
+			stats.MinValue = 0
+		}
 
 		// 计算平均值 平均值无意义，先暂时取消
 		// if stats.TotalCount > 0 {
@@ -96,6 +198,10 @@ func GenerateReport(data ReportData) (string, error) {
 		group.Stats = stats
 	}
 
+	if data.ShowDatasource() {
+		data.ClusterSummary = buildClusterSummary(*data)
+	}
+
 	// 处理图表数据
 	allLabels := make(map[string]bool)      // 用于存储所有唯一的标签值
 	chartData := make(map[string][]float64) // 用于存储图表数据
@@ -109,6 +215,9 @@ func GenerateReport(data ReportData) (string, error) {
 			labelValuesByMetric[metricKey] = make(map[string]bool)
 			// log.Println("指标组：", group.Type, "指标：", metricName, "指标键：", metricKey)
 			for _, metric := range metrics {
+				if !metric.HasValue() {
+					continue
+				}
 				for _, label := range metric.Labels {
 					labelValuesByMetric[metricKey][label.Value] = true
 					// log.Println("指标组：", group.Type, "指标：", metricName, "指标键：", metricKey, "标签值：", label.Value)
@@ -136,7 +245,7 @@ func GenerateReport(data ReportData) (string, error) {
 
 			// 填充实际的指标值
 			for _, metric := range metrics {
-				if len(metric.Labels) > 0 {
+				if metric.HasValue() && len(metric.Labels) > 0 {
 					metricValues[metric.Labels[0].Value] = metric.Value
 				}
 			}
@@ -167,26 +276,98 @@ func GenerateReport(data ReportData) (string, error) {
 		data.ChartData[key] = template.JS(valuesJSON)
 	}
 
-	// 生成报告
-	tmpl, err := template.ParseFiles("templates/report.html")
-	if err != nil {
-		return "", fmt.Errorf("parsing template: %w", err)
+	// 生成趋势图数据
+	for _, group := range data.MetricGroups {
+		for _, trend := range group.TrendsByName {
+			chart, err := trendChartJSON(trend)
+			if err != nil {
+				return fmt.Errorf("encoding trend chart: %w", err)
+			}
+			trend.Chart = chart
+		}
 	}
+	return nil
+}
 
-	// 创建输出文件
-	filename := fmt.Sprintf("reports/inspection_report_%s.html", time.Now().Format("20060102_150405"))
-	file, err := os.Create(filename)
-	if err != nil {
-		return "", fmt.Errorf("creating output file: %w", err)
+// buildClusterSummary 按集群和指标类型统计告警数量
+func buildClusterSummary(data ReportData) *ClusterSummary {
+	summary := &ClusterSummary{}
+	for groupType := range data.MetricGroups {
+		summary.Types = append(summary.Types, groupType)
+	}
+	sort.Strings(summary.Types)
+
+	rowIndex := make(map[string]int, len(data.Datasources))
+	for i, cluster := range data.Datasources {
+		rowIndex[cluster] = i
+		summary.Rows = append(summary.Rows, ClusterSummaryRow{
+			Cluster: cluster,
+			Cells:   make([]ClusterCell, len(summary.Types)),
+		})
+	}
+
+	for typeIndex, groupType := range summary.Types {
+		for _, metrics := range data.MetricGroups[groupType].MetricsByName {
+			for _, metric := range metrics {
+				i, ok := rowIndex[metric.Datasource]
+				if !ok {
+					continue
+				}
+				row := &summary.Rows[i]
+				for _, cell := range []*ClusterCell{&row.Cells[typeIndex], &row.Total} {
+					cell.TotalCount++
+					switch metric.Status {
+					case "critical":
+						cell.CriticalCount++
+					case "warning":
+						cell.WarningCount++
+					case "nodata":
+						cell.NoDataCount++
+					case "error":
+						cell.ErrorCount++
+					}
+				}
+			}
+		}
 	}
-	defer file.Close()
+	return summary
+}
 
-	// 执行模板
-	if err := tmpl.Execute(file, data); err != nil {
-		return "", fmt.Errorf("executing template: %w", err)
+// trendChartJSON 将趋势数据转换为 Chart.js 折线图数据
+func trendChartJSON(trend *TrendData) (template.JS, error) {
+	type dataset struct {
+		Label       string     `json:"label"`
+		Data        []*float64 `json:"data"`
+		BorderDash  []int      `json:"borderDash,omitempty"`
+		PointRadius int        `json:"pointRadius"`
 	}
 
-	// log.Println("Report generated successfully:", filename)
+	labels := make([]string, 0, len(trend.Timestamps))
+	for _, ts := range trend.Timestamps {
+		labels = append(labels, ts.Format("01-02 15:04"))
+	}
 
-	return filename, nil // 添加返回语句
+	datasets := make([]dataset, 0, len(trend.Series)+len(trend.Thresholds))
+	for _, series := range trend.Series {
+		datasets = append(datasets, dataset{Label: series.Name, Data: series.Values})
+	}
+
+	// 阈值线
+	for _, line := range trend.Thresholds {
+		value := line.Value
+		values := make([]*float64, len(trend.Timestamps))
+		for i := range values {
+			values[i] = &value
+		}
+		datasets = append(datasets, dataset{Label: line.Name, Data: values, BorderDash: []int{6, 4}})
+	}
+
+	chart, err := json.Marshal(map[string]interface{}{
+		"labels":   labels,
+		"datasets": datasets,
+	})
+	if err != nil {
+		return "", err
+	}
+	return template.JS(chart), nil
 }
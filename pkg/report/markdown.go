@@ -0,0 +1,130 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// markdownStatusIcons 状态在 Markdown 表格中的图标，GitHub 等平台不支持单元格颜色
+var markdownStatusIcons = map[string]string{
+	"critical": "🔴",
+	"warning":  "🟡",
+	"nodata":   "⚪",
+	"error":    "⚫",
+	"normal":   "🟢",
+}
+
+// markdownCell 转义表格单元格中的竖线和换行
+var markdownCell = strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ")
+
+// markdownRenderer 生成 GitHub 风格的 Markdown 报告，适合发布到 Wiki、Git 仓库和聊天工具
+type markdownRenderer struct{}
+
+func (markdownRenderer) Format() string { return FormatMarkdown }
+
+func (markdownRenderer) Render(out io.Writer, data *ReportData) error {
+	w := bufio.NewWriter(out)
+	types := sortedGroupTypes(*data)
+
+	fmt.Fprintf(w, "# Prometheus 巡检报告\n\n")
+	fmt.Fprintf(w, "生成时间：%s\n\n", data.Timestamp.Format("2006-01-02 15:04:05"))
+
+	// 概览
+	fmt.Fprintf(w, "## 概览\n\n")
+	markdownRow(w, "指标类型", "总数", "严重", "警告", "无数据", "查询失败")
+	fmt.Fprintf(w, "| --- | ---: | ---: | ---: | ---: | ---: |\n")
+	for _, groupType := range types {
+		stats := data.MetricGroups[groupType].Stats
+		markdownRow(w, groupType, fmt.Sprint(stats.TotalCount), fmt.Sprint(stats.CriticalCount),
+			fmt.Sprint(stats.WarningCount), fmt.Sprint(stats.NoDataCount), fmt.Sprint(stats.ErrorCount))
+	}
+	fmt.Fprintln(w)
+
+	if summary := data.ClusterSummary; summary != nil && len(summary.Rows) > 0 {
+		fmt.Fprintf(w, "## %s汇总\n\n", data.DatasourceTitle())
+		header := append([]string{data.DatasourceTitle()}, summary.Types...)
+		markdownRow(w, append(header, "合计")...)
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(summary.Types)+2))
+		for _, row := range summary.Rows {
+			cells := []string{row.Cluster}
+			for _, cell := range append(append([]ClusterCell{}, row.Cells...), row.Total) {
+				text := "-"
+				if cell.TotalCount > 0 {
+					text = fmt.Sprintf("严重 %d / 警告 %d", cell.CriticalCount, cell.WarningCount)
+				}
+				cells = append(cells, text)
+			}
+			markdownRow(w, cells...)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(data.TimedOutMetrics) > 0 {
+		fmt.Fprintf(w, "> **以下 %d 个查询超时，报告中缺少对应数据：**\n>\n", len(data.TimedOutMetrics))
+		for _, metric := range data.TimedOutMetrics {
+			text := metric.Type + " / " + metric.Name
+			if data.ShowDatasource() {
+				text += " @ " + metric.Datasource
+			}
+			fmt.Fprintf(w, "> - %s（超时时间 %v）\n", text, metric.Timeout)
+		}
+		fmt.Fprintln(w)
+	}
+
+	for _, groupType := range types {
+		group := data.MetricGroups[groupType]
+		fmt.Fprintf(w, "## %s 监控指标\n\n", groupType)
+		for _, name := range sortedMetricNames(group) {
+			metrics := group.MetricsByName[name]
+			if len(metrics) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "### %s\n\n", name)
+			markdownTable(w, data, metrics)
+			fmt.Fprintln(w)
+		}
+	}
+	return w.Flush()
+}
+
+// markdownTable 输出单个指标的数据表格，标签列使用别名作为表头
+func markdownTable(w io.Writer, data *ReportData, metrics []MetricData) {
+	showDatasource := data.ShowDatasource()
+	aliases := metricAliases(metrics)
+
+	var header []string
+	if showDatasource {
+		header = append(header, data.DatasourceTitle())
+	}
+	header = append(header, aliases...)
+	header = append(header, "当前值", "阈值", "状态")
+	markdownRow(w, header...)
+	align := strings.Repeat(" --- |", len(header)-3) + " ---: | ---: | --- |"
+	fmt.Fprintf(w, "|%s\n", align)
+
+	for _, metric := range metrics {
+		var cells []string
+		if showDatasource {
+			cells = append(cells, metric.Datasource)
+		}
+		for _, alias := range aliases {
+			cells = append(cells, labelByAlias(metric, alias))
+		}
+		status := metric.StatusText
+		if icon, ok := markdownStatusIcons[metric.Status]; ok {
+			status = icon + " " + status
+		}
+		cells = append(cells, valueText(metric), thresholdText(metric), status)
+		markdownRow(w, cells...)
+	}
+}
+
+// markdownRow 输出表格中的一行
+func markdownRow(w io.Writer, cells ...string) {
+	for i, cell := range cells {
+		cells[i] = markdownCell.Replace(cell)
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+}
@@ -0,0 +1,506 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+
+	"PromAI/pkg/config"
+)
+
+const (
+	pdfFont       = "report"
+	pdfRowHeight  = 7.0
+	pdfChartH     = 60.0
+	pdfCardHeight = 26.0
+	pdfLegendMax  = 12 // 图例最多显示的序列数
+)
+
+// rgb PDF 中使用的颜色
+type rgb struct{ r, g, b int }
+
+var (
+	pdfColorText    = rgb{51, 51, 51}
+	pdfColorMuted   = rgb{140, 140, 140}
+	pdfColorBorder  = rgb{232, 232, 232}
+	pdfColorHeader  = rgb{250, 250, 250}
+	pdfColorPrimary = rgb{24, 144, 255}
+
+	// pdfSeriesColors 趋势图序列颜色
+	pdfSeriesColors = []rgb{
+		{24, 144, 255}, {82, 196, 26}, {250, 140, 22}, {114, 46, 209}, {19, 194, 194},
+		{235, 47, 150}, {250, 219, 20}, {47, 84, 235}, {160, 217, 17}, {245, 34, 45},
+	}
+)
+
+// pdfStatusColors 返回状态对应的背景色和文字颜色，与 StatusBackground 和 StatusColor 一致
+func pdfStatusColors(status string) (rgb, rgb) {
+	return hexRGB(StatusBackground(status)), hexRGB(StatusColor(status))
+}
+
+// hexRGB 解析 #rrggbb 格式的颜色
+func hexRGB(color string) rgb {
+	var c rgb
+	fmt.Sscanf(color, "#%02x%02x%02x", &c.r, &c.g, &c.b)
+	return c
+}
+
+// pdfReport 生成 PDF 报告，使用 fpdf 直接绘制表格和趋势图，不依赖浏览器
+type pdfReport struct {
+	pdf  *fpdf.Fpdf
+	data ReportData
+}
+
+// pdfRenderer 生成 PDF 报告，需要配置支持中文的字体文件
+type pdfRenderer struct {
+	cfg config.PDFConfig
+}
+
+// NewPDFRenderer 创建使用指定字体配置的 PDF 渲染器
+func NewPDFRenderer(cfg config.PDFConfig) Renderer {
+	return pdfRenderer{cfg: cfg}
+}
+
+func (pdfRenderer) Format() string { return FormatPDF }
+
+func (p pdfRenderer) Render(w io.Writer, data *ReportData) error {
+	if p.cfg.FontFile == "" {
+		return fmt.Errorf("pdf.font_file is required to render PDF reports")
+	}
+
+	pdf := fpdf.New("L", "mm", "A4", "")
+	boldFont := p.cfg.BoldFontFile
+	if boldFont == "" {
+		boldFont = p.cfg.FontFile
+	}
+	for style, file := range map[string]string{"": p.cfg.FontFile, "B": boldFont} {
+		font, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading pdf font: %w", err)
+		}
+		pdf.AddUTF8FontFromBytes(pdfFont, style, font)
+	}
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("loading pdf font: %w", err)
+	}
+
+	r := &pdfReport{pdf: pdf, data: *data}
+	r.render()
+	if err := pdf.Error(); err != nil {
+		return fmt.Errorf("rendering pdf: %w", err)
+	}
+	return pdf.Output(w)
+}
+
+// render 依次绘制标题、汇总卡片、集群汇总、超时列表和各指标的表格及趋势图
+func (r *pdfReport) render() {
+	pdf := r.pdf
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.AliasNbPages("")
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-10)
+		r.font("", 8, pdfColorMuted)
+		pdf.CellFormat(0, 6, fmt.Sprintf("第 %d 页 / 共 {nb} 页", pdf.PageNo()), "", 0, "C", false, 0, "")
+	})
+	pdf.AddPage()
+
+	r.font("B", 18, pdfColorText)
+	pdf.CellFormat(0, 10, "Prometheus 巡检报告", "", 1, "L", false, 0, "")
+	r.font("", 9, pdfColorMuted)
+	pdf.CellFormat(0, 6, "生成时间: "+r.data.Timestamp.Format("2006-01-02 15:04:05"), "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	types := sortedGroupTypes(r.data)
+	r.summaryCards(types)
+	r.clusterSummary()
+	r.timedOut()
+
+	for _, groupType := range types {
+		group := r.data.MetricGroups[groupType]
+		r.ensureSpace(20)
+		r.heading(groupType + " 监控指标")
+
+		for _, name := range sortedMetricNames(group) {
+			metrics := group.MetricsByName[name]
+			if len(metrics) == 0 {
+				continue
+			}
+			r.ensureSpace(3 * pdfRowHeight)
+			r.font("B", 11, pdfColorText)
+			pdf.CellFormat(0, 8, name, "", 1, "L", false, 0, "")
+			r.metricTable(metrics)
+			if trend := group.TrendsByName[name]; trend != nil {
+				r.chart(name, trend)
+			}
+			pdf.Ln(3)
+		}
+	}
+}
+
+// font 设置字体和文字颜色
+func (r *pdfReport) font(style string, size float64, color rgb) {
+	r.pdf.SetFont(pdfFont, style, size)
+	r.pdf.SetTextColor(color.r, color.g, color.b)
+}
+
+// contentWidth 返回页面可用宽度
+func (r *pdfReport) contentWidth() float64 {
+	pageW, _ := r.pdf.GetPageSize()
+	left, _, right, _ := r.pdf.GetMargins()
+	return pageW - left - right
+}
+
+// ensureSpace 当前页剩余高度不足时换页，返回是否换页
+func (r *pdfReport) ensureSpace(height float64) bool {
+	_, pageH := r.pdf.GetPageSize()
+	_, _, _, bottom := r.pdf.GetMargins()
+	if r.pdf.GetY()+height > pageH-bottom {
+		r.pdf.AddPage()
+		return true
+	}
+	return false
+}
+
+// heading 绘制带左侧色条的章节标题
+func (r *pdfReport) heading(text string) {
+	pdf := r.pdf
+	x, y := pdf.GetX(), pdf.GetY()
+	pdf.SetFillColor(pdfColorPrimary.r, pdfColorPrimary.g, pdfColorPrimary.b)
+	pdf.Rect(x, y+1, 1.5, 8, "F")
+	pdf.SetX(x + 4)
+	r.font("B", 14, pdfColorText)
+	pdf.CellFormat(0, 10, text, "", 1, "L", false, 0, "")
+	pdf.Ln(1)
+}
+
+// fit 截断文字使其不超过指定宽度
+func (r *pdfReport) fit(text string, width float64) string {
+	width -= 2
+	if r.pdf.GetStringWidth(text) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && r.pdf.GetStringWidth(string(runes)+"…") > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "…"
+}
+
+// summaryCards 绘制每个指标类型的汇总卡片，每行三张
+func (r *pdfReport) summaryCards(types []string) {
+	pdf := r.pdf
+	const perRow, gap = 3, 4.0
+	cardW := (r.contentWidth() - gap*(perRow-1)) / perRow
+	left, _, _, _ := pdf.GetMargins()
+
+	rowY := pdf.GetY()
+	for i, groupType := range types {
+		if i%perRow == 0 {
+			if i > 0 {
+				pdf.SetY(rowY + pdfCardHeight + gap)
+			}
+			r.ensureSpace(pdfCardHeight)
+			rowY = pdf.GetY()
+		}
+		stats := r.data.MetricGroups[groupType].Stats
+		x, y := left+float64(i%perRow)*(cardW+gap), rowY
+
+		pdf.SetDrawColor(pdfColorBorder.r, pdfColorBorder.g, pdfColorBorder.b)
+		pdf.SetFillColor(255, 255, 255)
+		pdf.RoundedRect(x, y, cardW, pdfCardHeight, 2, "1234", "FD")
+
+		pdf.SetXY(x+4, y+2)
+		r.font("B", 11, pdfColorText)
+		pdf.CellFormat(cardW-8, 7, r.fit(groupType, cardW-8), "", 2, "L", false, 0, "")
+		r.font("", 9, pdfColorMuted)
+		pdf.CellFormat(cardW-8, 5, fmt.Sprintf("最大值 %.2f    最小值 %.2f", stats.MaxValue, stats.MinValue), "", 2, "L", false, 0, "")
+
+		alertColor := pdfColorText
+		if stats.AlertCount > 0 {
+			_, alertColor = pdfStatusColors("critical")
+		}
+		r.font("B", 9, alertColor)
+		pdf.CellFormat(cardW-8, 5, fmt.Sprintf("告警 %d/%d（严重 %d，警告 %d）",
+			stats.AlertCount, stats.TotalCount, stats.CriticalCount, stats.WarningCount), "", 2, "L", false, 0, "")
+		if stats.NoDataCount > 0 || stats.ErrorCount > 0 {
+			r.font("", 9, pdfColorMuted)
+			pdf.CellFormat(cardW-8, 5, fmt.Sprintf("无数据 %d，查询失败 %d", stats.NoDataCount, stats.ErrorCount), "", 2, "L", false, 0, "")
+		}
+	}
+	if len(types) > 0 {
+		pdf.SetY(rowY + pdfCardHeight + gap)
+	}
+}
+
+// clusterSummary 绘制集群 × 指标类型的告警汇总表
+func (r *pdfReport) clusterSummary() {
+	summary := r.data.ClusterSummary
+	if summary == nil || len(summary.Rows) == 0 {
+		return
+	}
+	pdf := r.pdf
+	r.ensureSpace(3 * pdfRowHeight)
+	r.heading(r.data.DatasourceTitle() + "汇总")
+
+	headers := append([]string{r.data.DatasourceTitle()}, summary.Types...)
+	headers = append(headers, "合计")
+	colW := r.contentWidth() / float64(len(headers))
+
+	drawHeader := func() {
+		r.font("B", 9, pdfColorText)
+		pdf.SetFillColor(pdfColorHeader.r, pdfColorHeader.g, pdfColorHeader.b)
+		pdf.SetDrawColor(pdfColorBorder.r, pdfColorBorder.g, pdfColorBorder.b)
+		for _, header := range headers {
+			pdf.CellFormat(colW, pdfRowHeight, r.fit(header, colW), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	drawHeader()
+
+	for _, row := range summary.Rows {
+		if r.ensureSpace(pdfRowHeight) {
+			drawHeader()
+		}
+		r.font("", 9, pdfColorText)
+		pdf.CellFormat(colW, pdfRowHeight, r.fit(row.Cluster, colW), "1", 0, "L", false, 0, "")
+		for _, cell := range append(append([]ClusterCell{}, row.Cells...), row.Total) {
+			status := "normal"
+			if cell.CriticalCount > 0 {
+				status = "critical"
+			} else if cell.WarningCount > 0 {
+				status = "warning"
+			}
+			fill, text := pdfStatusColors(status)
+			if status == "normal" {
+				text = pdfColorMuted
+			}
+			content := "-"
+			if cell.TotalCount > 0 {
+				content = fmt.Sprintf("严重 %d / 警告 %d", cell.CriticalCount, cell.WarningCount)
+			}
+			r.font("", 9, text)
+			pdf.SetFillColor(fill.r, fill.g, fill.b)
+			pdf.CellFormat(colW, pdfRowHeight, r.fit(content, colW), "1", 0, "C", true, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(4)
+}
+
+// timedOut 列出查询超时的指标
+func (r *pdfReport) timedOut() {
+	if len(r.data.TimedOutMetrics) == 0 {
+		return
+	}
+	pdf := r.pdf
+	r.ensureSpace(2 * pdfRowHeight)
+	r.font("B", 10, pdfStatusText("warning"))
+	pdf.CellFormat(0, pdfRowHeight, fmt.Sprintf("以下 %d 个查询超时，报告中缺少对应数据：", len(r.data.TimedOutMetrics)), "", 1, "L", false, 0, "")
+	r.font("", 9, pdfColorText)
+	for _, metric := range r.data.TimedOutMetrics {
+		text := metric.Type + " / " + metric.Name
+		if r.data.ShowDatasource() {
+			text += " @ " + metric.Datasource
+		}
+		text += fmt.Sprintf("（超时时间 %v）", metric.Timeout)
+		r.ensureSpace(5)
+		pdf.CellFormat(0, 5, r.fit(text, r.contentWidth()), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+}
+
+// pdfStatusText 返回状态对应的文字颜色
+func pdfStatusText(status string) rgb {
+	_, text := pdfStatusColors(status)
+	return text
+}
+
+// metricTable 绘制单个指标的数据表格，标签列使用别名作为表头，换页时重复表头
+func (r *pdfReport) metricTable(metrics []MetricData) {
+	pdf := r.pdf
+	showDatasource := r.data.ShowDatasource()
+	labels := metrics[0].Labels
+
+	const nameW, datasourceW, valueW, statusW, timeW = 40.0, 28.0, 28.0, 22.0, 34.0
+	fixed := nameW + valueW + statusW + timeW
+	if showDatasource {
+		fixed += datasourceW
+	}
+	labelW := 0.0
+	if len(labels) > 0 {
+		labelW = (r.contentWidth() - fixed) / float64(len(labels))
+	}
+	nameWidth := nameW
+	if len(labels) == 0 {
+		nameWidth = r.contentWidth() - fixed + nameW
+	}
+
+	drawHeader := func() {
+		r.font("B", 9, pdfColorText)
+		pdf.SetFillColor(pdfColorHeader.r, pdfColorHeader.g, pdfColorHeader.b)
+		pdf.SetDrawColor(pdfColorBorder.r, pdfColorBorder.g, pdfColorBorder.b)
+		pdf.CellFormat(nameWidth, pdfRowHeight, "指标名称", "1", 0, "L", true, 0, "")
+		if showDatasource {
+			pdf.CellFormat(datasourceW, pdfRowHeight, r.data.DatasourceTitle(), "1", 0, "L", true, 0, "")
+		}
+		for _, label := range labels {
+			pdf.CellFormat(labelW, pdfRowHeight, r.fit(label.Alias, labelW), "1", 0, "L", true, 0, "")
+		}
+		pdf.CellFormat(valueW, pdfRowHeight, "当前值", "1", 0, "R", true, 0, "")
+		pdf.CellFormat(statusW, pdfRowHeight, "状态", "1", 0, "C", true, 0, "")
+		pdf.CellFormat(timeW, pdfRowHeight, "检查时间", "1", 1, "C", true, 0, "")
+	}
+	drawHeader()
+
+	for _, metric := range metrics {
+		if r.ensureSpace(pdfRowHeight) {
+			drawHeader()
+		}
+		fill, statusColor := pdfStatusColors(metric.Status)
+		pdf.SetFillColor(fill.r, fill.g, fill.b)
+
+		r.font("", 8, pdfColorText)
+		pdf.CellFormat(nameWidth, pdfRowHeight, r.fit(metric.Name, nameWidth), "1", 0, "L", true, 0, "")
+		if showDatasource {
+			pdf.CellFormat(datasourceW, pdfRowHeight, r.fit(metric.Datasource, datasourceW), "1", 0, "L", true, 0, "")
+		}
+		for _, label := range labels {
+			value := ""
+			for _, metricLabel := range metric.Labels {
+				if metricLabel.Name == label.Name {
+					value = metricLabel.Value
+					break
+				}
+			}
+			pdf.CellFormat(labelW, pdfRowHeight, r.fit(value, labelW), "1", 0, "L", true, 0, "")
+		}
+
+		pdf.CellFormat(valueW, pdfRowHeight, r.fit(valueText(metric), valueW), "1", 0, "R", true, 0, "")
+		r.font("B", 8, statusColor)
+		pdf.CellFormat(statusW, pdfRowHeight, metric.StatusText, "1", 0, "C", true, 0, "")
+		r.font("", 8, pdfColorText)
+		pdf.CellFormat(timeW, pdfRowHeight, metric.Timestamp.Format("2006-01-02 15:04:05"), "1", 1, "C", true, 0, "")
+	}
+}
+
+// chart 绘制静态趋势图：坐标轴、各序列折线、虚线阈值线和图例
+func (r *pdfReport) chart(title string, trend *TrendData) {
+	minValue, maxValue := math.Inf(1), math.Inf(-1)
+	for _, series := range trend.Series {
+		for _, v := range series.Values {
+			if v != nil {
+				minValue, maxValue = math.Min(minValue, *v), math.Max(maxValue, *v)
+			}
+		}
+	}
+	if math.IsInf(minValue, 1) || len(trend.Timestamps) < 2 {
+		return
+	}
+	for _, threshold := range trend.Thresholds {
+		minValue, maxValue = math.Min(minValue, threshold.Value), math.Max(maxValue, threshold.Value)
+	}
+	if minValue == maxValue {
+		minValue, maxValue = minValue-1, maxValue+1
+	}
+	padding := (maxValue - minValue) * 0.05
+	minValue, maxValue = minValue-padding, maxValue+padding
+
+	legendRows := (int(math.Min(float64(len(trend.Series)), pdfLegendMax)) + 3) / 4
+	r.ensureSpace(pdfChartH + 12 + float64(legendRows)*5)
+
+	pdf := r.pdf
+	left, _, _, _ := pdf.GetMargins()
+	pdf.Ln(2)
+	r.font("", 9, pdfColorMuted)
+	pdf.CellFormat(0, 6, title+" 趋势"+unitSuffix(trend.Unit), "", 1, "L", false, 0, "")
+
+	const axisW = 18.0
+	x0, y0 := left+axisW, pdf.GetY()+2
+	w, h := r.contentWidth()-axisW, pdfChartH-10
+	toX := func(i int) float64 { return x0 + w*float64(i)/float64(len(trend.Timestamps)-1) }
+	toY := func(v float64) float64 { return y0 + h - h*(v-minValue)/(maxValue-minValue) }
+
+	// 网格和坐标轴刻度
+	pdf.SetLineWidth(0.1)
+	pdf.SetDrawColor(pdfColorBorder.r, pdfColorBorder.g, pdfColorBorder.b)
+	r.font("", 7, pdfColorMuted)
+	for i := 0; i <= 4; i++ {
+		v := minValue + (maxValue-minValue)*float64(i)/4
+		y := toY(v)
+		pdf.Line(x0, y, x0+w, y)
+		pdf.SetXY(left, y-2)
+		pdf.CellFormat(axisW-1, 4, fmt.Sprintf("%.2f", v), "", 0, "R", false, 0, "")
+	}
+	last := len(trend.Timestamps) - 1
+	for _, i := range []int{0, last / 2, last} {
+		pdf.SetXY(toX(i)-10, y0+h+1)
+		pdf.CellFormat(20, 4, trend.Timestamps[i].Format("01-02 15:04"), "", 0, "C", false, 0, "")
+	}
+
+	// 序列折线，缺失的采样点断开
+	pdf.SetLineWidth(0.4)
+	for s, series := range trend.Series {
+		color := pdfSeriesColors[s%len(pdfSeriesColors)]
+		pdf.SetDrawColor(color.r, color.g, color.b)
+		for i := 1; i < len(series.Values); i++ {
+			if series.Values[i-1] != nil && series.Values[i] != nil {
+				pdf.Line(toX(i-1), toY(*series.Values[i-1]), toX(i), toY(*series.Values[i]))
+			}
+		}
+	}
+
+	// 阈值线
+	pdf.SetLineWidth(0.3)
+	pdf.SetDashPattern([]float64{2, 1.5}, 0)
+	for _, threshold := range trend.Thresholds {
+		color := pdfStatusText("critical")
+		if strings.Contains(threshold.Name, "警告") {
+			color = pdfStatusText("warning")
+		}
+		pdf.SetDrawColor(color.r, color.g, color.b)
+		y := toY(threshold.Value)
+		pdf.Line(x0, y, x0+w, y)
+		r.font("", 7, color)
+		pdf.SetXY(x0+w-40, y-4)
+		pdf.CellFormat(40, 4, fmt.Sprintf("%s %.2f", threshold.Name, threshold.Value), "", 0, "R", false, 0, "")
+	}
+	pdf.SetDashPattern([]float64{}, 0)
+	pdf.SetLineWidth(0.2)
+
+	// 图例
+	pdf.SetY(y0 + h + 6)
+	legendW := r.contentWidth() / 4
+	for s, series := range trend.Series {
+		if s == pdfLegendMax {
+			r.font("", 7, pdfColorMuted)
+			pdf.SetX(left)
+			pdf.CellFormat(0, 5, fmt.Sprintf("另有 %d 条序列未在图例中显示", len(trend.Series)-pdfLegendMax), "", 1, "L", false, 0, "")
+			break
+		}
+		color := pdfSeriesColors[s%len(pdfSeriesColors)]
+		x := left + float64(s%4)*legendW
+		if s%4 == 0 && s > 0 {
+			pdf.Ln(5)
+		}
+		y := pdf.GetY()
+		pdf.SetFillColor(color.r, color.g, color.b)
+		pdf.Rect(x, y+1.5, 4, 2, "F")
+		pdf.SetXY(x+5, y)
+		r.font("", 7, pdfColorText)
+		pdf.CellFormat(legendW-6, 5, r.fit(series.Name, legendW-6), "", 0, "L", false, 0, "")
+	}
+	pdf.Ln(6)
+}
+
+// unitSuffix 返回趋势图标题中的单位说明
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return "（" + unit + "）"
+}
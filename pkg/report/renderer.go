@@ -0,0 +1,141 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+
+	"PromAI/pkg/config"
+)
+
+// 报告格式，同时作为报告文件的扩展名
+const (
+	FormatHTML     = "html"
+	FormatPDF      = "pdf"
+	FormatXLSX     = "xlsx"
+	FormatCSV      = "csv"
+	FormatMarkdown = "md"
+	FormatText     = "txt"
+	FormatJSON     = "json"
+)
+
+// Renderer 将巡检数据渲染为一种格式的报告
+type Renderer interface {
+	// Format 返回报告格式，未实现 Extension 方法时同时作为报告文件的扩展名
+	Format() string
+	// Render 将报告写入 w，data 已经过 PrepareReport 处理
+	Render(w io.Writer, data *ReportData) error
+}
+
+// extensionRenderer 报告文件扩展名与格式名称不同的渲染器，例如自定义模板
+type extensionRenderer interface {
+	Extension() string
+}
+
+// rendererExtension 返回渲染器生成的报告文件扩展名
+func rendererExtension(r Renderer) string {
+	if e, ok := r.(extensionRenderer); ok {
+		return e.Extension()
+	}
+	return r.Format()
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+func init() {
+	for _, r := range []Renderer{
+		templateRenderer{format: FormatHTML, extension: FormatHTML, file: "report.html"},
+		NewPDFRenderer(config.PDFConfig{}), // 未配置字体时生成 PDF 会报错，main 中按配置重新注册
+		xlsxRenderer{},
+		csvRenderer{},
+		markdownRenderer{},
+		textRenderer{},
+		jsonRenderer{},
+	} {
+		RegisterRenderer(r)
+	}
+}
+
+// RegisterRenderer 注册报告渲染器，格式相同时替换已有的渲染器
+func RegisterRenderer(r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	renderers[r.Format()] = r
+}
+
+// LookupRenderer 返回指定格式的渲染器，空字符串表示 html
+func LookupRenderer(format string) (Renderer, bool) {
+	if format == "" {
+		format = FormatHTML
+	}
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[format]
+	return r, ok
+}
+
+// SupportedFormat 是否为支持的报告格式，空字符串表示 html
+func SupportedFormat(format string) bool {
+	_, ok := LookupRenderer(format)
+	return ok
+}
+
+// Formats 返回所有已注册的报告格式
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	formats := make([]string, 0, len(renderers))
+	for format := range renderers {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// GenerateReport 生成指定格式的报告并写入元数据，format 为空时生成 HTML 报告，返回报告文件路径
+func GenerateReport(data ReportData, format string) (string, error) {
+	renderer, ok := LookupRenderer(format)
+	if !ok {
+		return "", fmt.Errorf("unsupported report format: %q", format)
+	}
+	if err := PrepareReport(&data); err != nil {
+		return "", err
+	}
+
+	id, file, err := createReportFile(rendererExtension(renderer))
+	if err != nil {
+		return "", err
+	}
+	filename := file.Name()
+	if err := renderer.Render(file, &data); err != nil {
+		file.Close()
+		os.Remove(filename)
+		return "", fmt.Errorf("rendering %s report: %w", renderer.Format(), err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("writing output file: %w", err)
+	}
+
+	// 写入报告元数据，供报告目录使用
+	if err := writeMeta(filename, id, data); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// jsonRenderer 以 JSON 保存巡检数据，内容与 /api/v1/report 一致
+type jsonRenderer struct{}
+
+func (jsonRenderer) Format() string { return FormatJSON }
+
+func (jsonRenderer) Render(w io.Writer, data *ReportData) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
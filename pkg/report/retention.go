@@ -0,0 +1,132 @@
+package report
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"PromAI/pkg/config"
+)
+
+// ApplyRetention 按保留策略删除过期的历史报告，返回被删除的报告
+// 最新的一份报告始终保留
+func ApplyRetention(retention config.RetentionConfig, now time.Time) ([]ReportMeta, error) {
+	if !retention.Enabled() {
+		return nil, nil
+	}
+	maxTotalBytes, err := retention.MaxTotalBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	reports, err := ListReports()
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []ReportMeta
+	var count int
+	var totalSize int64
+	days := make(map[string]bool)
+	for i, meta := range reports {
+		age := now.Sub(meta.Timestamp)
+		remove := false
+
+		switch {
+		case i == 0:
+			// 最新的报告始终保留
+		case retention.MaxAge > 0 && age > retention.MaxAge:
+			remove = true
+		case retention.KeepAllWithin > 0 && age > retention.KeepAllWithin:
+			// 报告按时间倒序排列，每天第一次出现的就是当天最后一份报告
+			day := meta.Timestamp.Local().Format("2006-01-02")
+			remove = days[day]
+			days[day] = true
+		}
+
+		if !remove && i > 0 {
+			remove = (retention.MaxCount > 0 && count >= retention.MaxCount) ||
+				(maxTotalBytes > 0 && totalSize+meta.Size > maxTotalBytes)
+		}
+		if !remove {
+			count++
+			totalSize += meta.Size
+			continue
+		}
+
+		if err := removeReport(meta); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, meta)
+	}
+	return deleted, nil
+}
+
+// removeReport 删除报告文件及其元数据
+func removeReport(meta ReportMeta) error {
+	if err := os.Remove(filepath.Join(ReportDir, meta.File)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing report: %w", err)
+	}
+	if err := os.Remove(metaPath(meta.ID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing report meta: %w", err)
+	}
+	return nil
+}
+
+// Janitor 在后台按保留策略清理历史报告
+// 启动时执行一次，之后每次生成报告后通过 Trigger 触发
+type Janitor struct {
+	retention config.RetentionConfig
+	trigger   chan struct{}
+}
+
+// NewJanitor 创建报告清理器
+func NewJanitor(retention config.RetentionConfig) *Janitor {
+	return &Janitor{
+		retention: retention,
+		trigger:   make(chan struct{}, 1),
+	}
+}
+
+// Start 启动后台清理协程，ctx 结束时退出
+func (j *Janitor) Start(ctx context.Context) {
+	if j == nil || !j.retention.Enabled() {
+		return
+	}
+	go func() {
+		j.run()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-j.trigger:
+				j.run()
+			}
+		}
+	}()
+}
+
+// Trigger 通知清理器执行一次清理，已有待执行的清理时直接返回
+func (j *Janitor) Trigger() {
+	if j == nil {
+		return
+	}
+	select {
+	case j.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// run 执行一次清理
+func (j *Janitor) run() {
+	deleted, err := ApplyRetention(j.retention, time.Now())
+	for _, meta := range deleted {
+		log.Printf("按保留策略删除报告: %s", meta.File)
+	}
+	if err != nil {
+		log.Printf("清理历史报告失败: %v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"PromAI/pkg/config"
+)
+
+func TestApplyRetention(t *testing.T) {
+	now := time.Date(2024, 12, 27, 12, 0, 0, 0, time.Local)
+
+	// 报告按时间从新到旧，每份 400 字节
+	reports := []time.Time{
+		now.Add(-1 * time.Hour),                // 12-27 11:00
+		now.Add(-2 * time.Hour),                // 12-27 10:00
+		now.Add(-26 * time.Hour),               // 12-26 10:00
+		now.Add(-30 * time.Hour),               // 12-26 06:00
+		now.Add(-5 * 24 * time.Hour),           // 12-22 12:00
+		now.Add(-5*24*time.Hour - 3*time.Hour), // 12-22 09:00
+		now.Add(-40 * 24 * time.Hour),          // 11-17 12:00
+	}
+	ids := make([]string, len(reports))
+	for i, ts := range reports {
+		ids[i] = reportID(ts)
+	}
+
+	tests := []struct {
+		name      string
+		retention config.RetentionConfig
+		deleted   []string
+	}{
+		{
+			name:      "disabled",
+			retention: config.RetentionConfig{},
+		},
+		{
+			name:      "max_age",
+			retention: config.RetentionConfig{MaxAge: 30 * 24 * time.Hour},
+			deleted:   []string{ids[6]},
+		},
+		{
+			name:      "max_age keeps the latest report",
+			retention: config.RetentionConfig{MaxAge: time.Minute},
+			deleted:   ids[1:],
+		},
+		{
+			name:      "max_count",
+			retention: config.RetentionConfig{MaxCount: 3},
+			deleted:   ids[3:],
+		},
+		{
+			name:      "max_total_size",
+			retention: config.RetentionConfig{MaxTotalSize: "1KB"},
+			deleted:   ids[2:],
+		},
+		{
+			name:      "max_total_size keeps the latest report",
+			retention: config.RetentionConfig{MaxTotalSize: "100"},
+			deleted:   ids[1:],
+		},
+		{
+			name:      "keep_all_within",
+			retention: config.RetentionConfig{KeepAllWithin: 24 * time.Hour},
+			deleted:   []string{ids[3], ids[5]},
+		},
+		{
+			name:      "keep_all_within and max_age",
+			retention: config.RetentionConfig{KeepAllWithin: 24 * time.Hour, MaxAge: 30 * 24 * time.Hour},
+			deleted:   []string{ids[3], ids[5], ids[6]},
+		},
+		{
+			name:      "keep_all_within and max_count",
+			retention: config.RetentionConfig{KeepAllWithin: 24 * time.Hour, MaxCount: 4},
+			deleted:   []string{ids[3], ids[5], ids[6]},
+		},
+		{
+			name:      "keep_all_within and max_total_size",
+			retention: config.RetentionConfig{KeepAllWithin: 24 * time.Hour, MaxTotalSize: "1.2KB"},
+			deleted:   []string{ids[3], ids[4], ids[5], ids[6]},
+		},
+		{
+			name:      "all limits",
+			retention: config.RetentionConfig{MaxAge: 3 * 24 * time.Hour, MaxCount: 5, MaxTotalSize: "2KB", KeepAllWithin: 24 * time.Hour},
+			deleted:   []string{ids[3], ids[4], ids[5], ids[6]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			defer func(dir string) { ReportDir = dir }(ReportDir)
+			ReportDir = dir
+
+			for _, id := range ids {
+				name := filepath.Join(dir, reportPrefix+id+".html")
+				if err := os.WriteFile(name, []byte(strings.Repeat("x", 400)), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			deleted, err := ApplyRetention(tt.retention, now)
+			if err != nil {
+				t.Fatalf("ApplyRetention: %v", err)
+			}
+
+			var got []string
+			for _, meta := range deleted {
+				got = append(got, meta.ID)
+				if _, err := os.Stat(filepath.Join(dir, meta.File)); !os.IsNotExist(err) {
+					t.Errorf("report %s was not removed", meta.File)
+				}
+			}
+			want := append([]string(nil), tt.deleted...)
+			sort.Strings(got)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("deleted %v, want %v", got, want)
+			}
+
+			remaining, err := ListReports()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(remaining)+len(got) != len(ids) {
+				t.Errorf("%d reports remaining, want %d", len(remaining), len(ids)-len(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// statusSeverity 状态的严重程度，用于排序，正常为 0
+var statusSeverity = map[string]int{
+	"critical": 4,
+	"error":    3,
+	"warning":  2,
+	"nodata":   1,
+}
+
+// StatusSeverity 返回报告状态的严重程度，数值越大越严重，正常和未知状态为 0
+func StatusSeverity(status string) int {
+	return statusSeverity[status]
+}
+
+// sortedGroupTypes 返回排序后的指标类型，除 HTML 外的报告按此顺序输出
+func sortedGroupTypes(data ReportData) []string {
+	types := make([]string, 0, len(data.MetricGroups))
+	for groupType := range data.MetricGroups {
+		types = append(types, groupType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// sortedMetricNames 返回指标类型下排序后的指标名称
+func sortedMetricNames(group *MetricGroup) []string {
+	names := make([]string, 0, len(group.MetricsByName))
+	for name := range group.MetricsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// labelAliases 按出现顺序返回指标类型中所有标签的别名，作为表格的标签列
+func labelAliases(groups ...*MetricGroup) []string {
+	var metrics []MetricData
+	for _, group := range groups {
+		for _, name := range sortedMetricNames(group) {
+			metrics = append(metrics, group.MetricsByName[name]...)
+		}
+	}
+	return metricAliases(metrics)
+}
+
+// metricAliases 按出现顺序返回记录中所有标签的别名
+func metricAliases(metrics []MetricData) []string {
+	var aliases []string
+	seen := make(map[string]bool)
+	for _, metric := range metrics {
+		for _, label := range metric.Labels {
+			if !seen[label.Alias] {
+				seen[label.Alias] = true
+				aliases = append(aliases, label.Alias)
+			}
+		}
+	}
+	return aliases
+}
+
+// labelByAlias 返回指定别名的标签值，不存在时为空
+func labelByAlias(metric MetricData, alias string) string {
+	for _, label := range metric.Labels {
+		if label.Alias == alias {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// thresholdText 阈值的文本描述，区间和分段阈值使用 ThresholdDesc
+func thresholdText(metric MetricData) string {
+	if metric.ThresholdDesc != "" {
+		return metric.ThresholdDesc
+	}
+	return strconv.FormatFloat(metric.Threshold, 'f', -1, 64)
+}
+
+// FormatLabels 将标签格式化为 "别名=值" 的列表，没有别名时使用标签名
+func FormatLabels(labels []LabelData) string {
+	parts := make([]string, 0, len(labels))
+	for _, label := range labels {
+		name := label.Alias
+		if name == "" {
+			name = label.Name
+		}
+		parts = append(parts, name+"="+label.Value)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// valueText 带单位的当前值，保留两位小数，没有值时为 -
+func valueText(metric MetricData) string {
+	if !metric.HasValue() {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f%s", metric.Value, metric.Unit)
+}
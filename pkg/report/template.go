@@ -0,0 +1,219 @@
+package report
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"PromAI/pkg/config"
+	"PromAI/templates"
+)
+
+// 模板类型
+const (
+	TemplateTypeHTML = "html"
+	TemplateTypeText = "text"
+)
+
+var (
+	profileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	extensionPattern   = regexp.MustCompile(`^[a-z0-9]+$`)
+)
+
+// templateRenderer 使用 Go 模板生成报告，内置的 HTML 报告和自定义模板共用
+// 模板在每次生成报告时重新解析，修改 templates.dir 中的模板后无需重启
+type templateRenderer struct {
+	format    string // 报告格式名称
+	extension string // 报告文件扩展名
+	file      string // 模板文件
+	text      bool   // 使用 text/template，不转义输出内容
+}
+
+func (r templateRenderer) Format() string { return r.format }
+
+func (r templateRenderer) Extension() string { return r.extension }
+
+// executor html/template 和 text/template 模板的共同方法
+type executor interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// parse 读取并解析模板
+func (r templateRenderer) parse() (executor, error) {
+	content, err := templates.ReadFile(r.file)
+	if err != nil {
+		return nil, fmt.Errorf("reading template %s: %w", r.file, err)
+	}
+	name := filepath.Base(r.file)
+	if r.text {
+		tmpl, err := texttemplate.New(name).Funcs(TemplateFuncs()).Parse(string(content))
+		if err != nil {
+			return nil, fmt.Errorf("parsing template %s: %w", r.file, err)
+		}
+		return tmpl, nil
+	}
+	tmpl, err := template.New(name).Funcs(TemplateFuncs()).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", r.file, err)
+	}
+	return tmpl, nil
+}
+
+func (r templateRenderer) Render(w io.Writer, data *ReportData) error {
+	tmpl, err := r.parse()
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("executing template %s: %w", r.file, err)
+	}
+	return nil
+}
+
+// validate 使用示例数据执行模板，模板错误或引用了不存在的字段时返回错误
+func (r templateRenderer) validate() error {
+	data := sampleReportData()
+	if err := PrepareReport(&data); err != nil {
+		return err
+	}
+	return r.Render(io.Discard, &data)
+}
+
+// RegisterProfiles 校验并注册使用自定义模板的报告
+func RegisterProfiles(profiles []config.ReportProfile) error {
+	names := make(map[string]bool, len(profiles))
+	for _, profile := range profiles {
+		if !profileNamePattern.MatchString(profile.Name) {
+			return fmt.Errorf("invalid report profile name %q: use lowercase letters, digits, - and _", profile.Name)
+		}
+		if names[profile.Name] || SupportedFormat(profile.Name) {
+			return fmt.Errorf("report profile %s conflicts with an existing report format", profile.Name)
+		}
+		names[profile.Name] = true
+		if profile.Template == "" {
+			return fmt.Errorf("report profile %s: template is required", profile.Name)
+		}
+
+		ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(profile.Template), "."))
+		templateType := profile.Type
+		if templateType == "" {
+			templateType = TemplateTypeText
+			if ext == "html" || ext == "htm" {
+				templateType = TemplateTypeHTML
+			}
+		}
+		if templateType != TemplateTypeHTML && templateType != TemplateTypeText {
+			return fmt.Errorf("report profile %s: invalid type %q", profile.Name, profile.Type)
+		}
+		if profile.Extension != "" {
+			ext = profile.Extension
+		} else if ext == "" {
+			ext = FormatText
+			if templateType == TemplateTypeHTML {
+				ext = FormatHTML
+			}
+		}
+		if !extensionPattern.MatchString(ext) {
+			return fmt.Errorf("report profile %s: invalid extension %q", profile.Name, ext)
+		}
+
+		renderer := templateRenderer{
+			format:    profile.Name,
+			extension: ext,
+			file:      profile.Template,
+			text:      templateType == TemplateTypeText,
+		}
+		if err := renderer.validate(); err != nil {
+			return fmt.Errorf("report profile %s: %w", profile.Name, err)
+		}
+		RegisterRenderer(renderer)
+	}
+	return nil
+}
+
+// ValidateTemplates 使用示例数据校验所有基于模板的报告，包括被 templates.dir 覆盖的内置模板
+func ValidateTemplates() error {
+	for _, format := range Formats() {
+		renderer, _ := LookupRenderer(format)
+		if r, ok := renderer.(templateRenderer); ok {
+			if err := r.validate(); err != nil {
+				return fmt.Errorf("report format %s: %w", format, err)
+			}
+		}
+	}
+	return nil
+}
+
+// sampleReportData 校验模板使用的示例数据，覆盖多数据源、区间阈值、无数据、查询失败、趋势图和查询超时等情况
+func sampleReportData() ReportData {
+	now := time.Now()
+	metric := func(name, datasource string, value float64, status string, labels ...LabelData) MetricData {
+		m := MetricData{
+			Name:       name,
+			Value:      value,
+			Threshold:  80,
+			Unit:       "%",
+			Status:     status,
+			StatusText: GetStatusText(status),
+			Timestamp:  now,
+			Labels:     labels,
+			Query:      "sample_query",
+			Datasource: datasource,
+		}
+		if !m.HasValue() {
+			m.Value = 0
+			m.Error = "sample error"
+		}
+		return m
+	}
+	node := func(value string) LabelData { return LabelData{Name: "instance", Alias: "节点", Value: value} }
+	mount := LabelData{Name: "mountpoint", Alias: "挂载点", Value: "/"}
+
+	cpu := []MetricData{
+		metric("CPU使用率", "cluster-a", 35.5, "normal", node("10.0.0.1:9100")),
+		metric("CPU使用率", "cluster-b", 92.1, "critical", node("10.0.0.2:9100")),
+	}
+	cpu[1].ThresholdDesc = "正常区间: [0%, 80%]"
+	cpu[1].Override = "sample-override"
+	disk := []MetricData{
+		metric("磁盘使用率", "cluster-a", 81.2, "warning", node("10.0.0.1:9100"), mount),
+		metric("磁盘使用率", "cluster-b", 0, "nodata", node("10.0.0.2:9100"), mount),
+	}
+	redis := []MetricData{
+		metric("Redis 连接数", "cluster-a", 0, "error", LabelData{Name: "instance", Alias: "实例", Value: "redis-0"}),
+	}
+
+	v1, v2 := 30.0, 40.0
+	trend := &TrendData{
+		Timestamps: []time.Time{now.Add(-time.Hour), now},
+		Series:     []TrendSeries{{Name: "10.0.0.1:9100", Values: []*float64{&v1, &v2}}, {Name: "10.0.0.2:9100", Values: []*float64{nil, &v2}}},
+		Thresholds: []TrendThreshold{{Name: "阈值", Value: 80}},
+		Unit:       "%",
+	}
+
+	return ReportData{
+		Timestamp: now,
+		MetricGroups: map[string]*MetricGroup{
+			"基础资源": {
+				Type:          "基础资源",
+				MetricsByName: map[string][]MetricData{"CPU使用率": cpu, "磁盘使用率": disk},
+				TrendsByName:  map[string]*TrendData{"CPU使用率": trend},
+			},
+			"中间件": {
+				Type:          "中间件",
+				MetricsByName: map[string][]MetricData{"Redis 连接数": redis},
+				TrendsByName:  map[string]*TrendData{},
+			},
+		},
+		TimedOutMetrics: []TimedOutMetric{
+			{Type: "中间件", Name: "Redis 内存", Datasource: "cluster-b", Query: "sample_query", Timeout: 10 * time.Second},
+		},
+		Datasources: []string{"cluster-a", "cluster-b"},
+		Fleet:       true,
+	}
+}
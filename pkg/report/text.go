@@ -0,0 +1,76 @@
+package report
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// textRenderer 生成紧凑的纯文本摘要：总体统计、各指标类型统计和所有异常记录
+type textRenderer struct{}
+
+func (textRenderer) Format() string { return FormatText }
+
+func (textRenderer) Render(out io.Writer, data *ReportData) error {
+	w := bufio.NewWriter(out)
+	counts := countStatuses(*data)
+	showDatasource := data.ShowDatasource()
+
+	fmt.Fprintf(w, "Prometheus 巡检报告 %s\n", data.Timestamp.Format("2006-01-02 15:04:05"))
+	fmt.Fprintf(w, "共 %d 项：严重 %d，警告 %d，无数据 %d，查询失败 %d\n\n",
+		counts.Total, counts.Critical, counts.Warning, counts.NoData, counts.Error)
+
+	type row struct {
+		groupType string
+		metric    MetricData
+	}
+	var abnormal []row
+	for _, groupType := range sortedGroupTypes(*data) {
+		group := data.MetricGroups[groupType]
+		stats := group.Stats
+		fmt.Fprintf(w, "%s：共 %d，严重 %d，警告 %d，无数据 %d，查询失败 %d\n", groupType,
+			stats.TotalCount, stats.CriticalCount, stats.WarningCount, stats.NoDataCount, stats.ErrorCount)
+		for _, name := range sortedMetricNames(group) {
+			for _, metric := range group.MetricsByName[name] {
+				if statusSeverity[metric.Status] > 0 {
+					abnormal = append(abnormal, row{groupType, metric})
+				}
+			}
+		}
+	}
+
+	if len(abnormal) > 0 {
+		fmt.Fprintf(w, "\n异常项（%d）：\n", len(abnormal))
+		sort.SliceStable(abnormal, func(i, j int) bool {
+			return statusSeverity[abnormal[i].metric.Status] > statusSeverity[abnormal[j].metric.Status]
+		})
+		for _, r := range abnormal {
+			metric := r.metric
+			name := r.groupType + " / " + metric.Name
+			if showDatasource {
+				name += " @ " + metric.Datasource
+			}
+			detail := fmt.Sprintf("%s（阈值 %s）", valueText(metric), thresholdText(metric))
+			if !metric.HasValue() && metric.Error != "" {
+				detail = metric.Error
+			}
+			if labels := FormatLabels(metric.Labels); labels != "" {
+				name += " " + labels
+			}
+			fmt.Fprintf(w, "[%s] %s %s\n", metric.StatusText, name, detail)
+		}
+	}
+
+	if len(data.TimedOutMetrics) > 0 {
+		fmt.Fprintf(w, "\n查询超时（%d）：\n", len(data.TimedOutMetrics))
+		for _, metric := range data.TimedOutMetrics {
+			name := metric.Type + " / " + metric.Name
+			if showDatasource {
+				name += " @ " + metric.Datasource
+			}
+			fmt.Fprintf(w, "%s（超时时间 %v）\n", name, metric.Timeout)
+		}
+	}
+	return w.Flush()
+}
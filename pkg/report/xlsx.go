@@ -0,0 +1,317 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+const (
+	xlsxSummarySheet = "概览"
+	xlsxMaxSheetName = 31 // Excel 工作表名称的最大长度
+)
+
+// xlsxInvalidSheetChars 工作表名称中不允许出现的字符
+var xlsxInvalidSheetChars = strings.NewReplacer(":", "_", "\\", "_", "/", "_", "?", "_", "*", "_", "[", "(", "]", ")")
+
+// xlsxStatuses 需要条件格式着色的状态，颜色使用 StatusBackground 和 StatusColor
+var xlsxStatuses = []string{"critical", "error", "warning", "nodata"}
+
+// xlsxReport 生成 XLSX 报告：概览工作表加每个指标类型一个工作表
+type xlsxReport struct {
+	file *excelize.File
+	data ReportData
+
+	headerStyle int
+	titleStyle  int
+	numberStyle int
+	timeStyle   int
+	statusStyle map[string]int // 条件格式样式
+	sheetNames  map[string]bool
+}
+
+// xlsxRenderer 生成 XLSX 报告
+type xlsxRenderer struct{}
+
+func (xlsxRenderer) Format() string { return FormatXLSX }
+
+func (xlsxRenderer) Render(w io.Writer, data *ReportData) error {
+	file := excelize.NewFile()
+	defer file.Close()
+
+	x := &xlsxReport{file: file, data: *data, sheetNames: make(map[string]bool)}
+	if err := x.render(); err != nil {
+		return fmt.Errorf("rendering xlsx: %w", err)
+	}
+	if err := file.Write(w); err != nil {
+		return fmt.Errorf("writing xlsx: %w", err)
+	}
+	return nil
+}
+
+// render 依次生成样式、概览工作表和各指标类型的工作表
+func (x *xlsxReport) render() error {
+	if err := x.createStyles(); err != nil {
+		return err
+	}
+
+	types := sortedGroupTypes(x.data)
+	sheets := make([]string, len(types))
+	for i, groupType := range types {
+		sheets[i] = x.sheetName(groupType)
+	}
+
+	if err := x.file.SetSheetName("Sheet1", xlsxSummarySheet); err != nil {
+		return err
+	}
+	if err := x.summarySheet(types, sheets); err != nil {
+		return err
+	}
+	for i, groupType := range types {
+		if _, err := x.file.NewSheet(sheets[i]); err != nil {
+			return err
+		}
+		if err := x.groupSheet(sheets[i], x.data.MetricGroups[groupType]); err != nil {
+			return fmt.Errorf("sheet %s: %w", sheets[i], err)
+		}
+	}
+	return nil
+}
+
+// createStyles 创建表头、数值、时间和各状态的样式
+func (x *xlsxReport) createStyles() error {
+	var err error
+	border := []excelize.Border{
+		{Type: "left", Color: "#E8E8E8", Style: 1},
+		{Type: "right", Color: "#E8E8E8", Style: 1},
+		{Type: "top", Color: "#E8E8E8", Style: 1},
+		{Type: "bottom", Color: "#E8E8E8", Style: 1},
+	}
+	if x.headerStyle, err = x.file.NewStyle(&excelize.Style{
+		Font:   &excelize.Font{Bold: true},
+		Fill:   excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"#FAFAFA"}},
+		Border: border,
+	}); err != nil {
+		return err
+	}
+	if x.titleStyle, err = x.file.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Size: 16},
+	}); err != nil {
+		return err
+	}
+	numberFormat := "0.00"
+	if x.numberStyle, err = x.file.NewStyle(&excelize.Style{CustomNumFmt: &numberFormat}); err != nil {
+		return err
+	}
+	timeFormat := "yyyy-mm-dd hh:mm:ss"
+	if x.timeStyle, err = x.file.NewStyle(&excelize.Style{CustomNumFmt: &timeFormat}); err != nil {
+		return err
+	}
+
+	x.statusStyle = make(map[string]int, len(xlsxStatuses))
+	for _, status := range xlsxStatuses {
+		style, err := x.file.NewConditionalStyle(&excelize.Style{
+			Font: &excelize.Font{Color: StatusColor(status)},
+			Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{StatusBackground(status)}},
+		})
+		if err != nil {
+			return err
+		}
+		x.statusStyle[status] = style
+	}
+	return nil
+}
+
+// sheetName 将指标类型转换为合法且不重复的工作表名称
+func (x *xlsxReport) sheetName(groupType string) string {
+	name := strings.Trim(xlsxInvalidSheetChars.Replace(groupType), "'")
+	if name == "" {
+		name = "指标"
+	}
+	base := []rune(name)
+	for i := 1; ; i++ {
+		candidate := base
+		suffix := ""
+		if i > 1 {
+			suffix = fmt.Sprintf("(%d)", i)
+		}
+		if limit := xlsxMaxSheetName - len([]rune(suffix)); len(candidate) > limit {
+			candidate = candidate[:limit]
+		}
+		name = string(candidate) + suffix
+		if key := strings.ToLower(name); name != xlsxSummarySheet && !x.sheetNames[key] {
+			x.sheetNames[key] = true
+			return name
+		}
+	}
+}
+
+// summarySheet 生成概览工作表：生成时间、各指标类型的统计和查询超时的指标
+func (x *xlsxReport) summarySheet(types, sheets []string) error {
+	f, sheet := x.file, xlsxSummarySheet
+
+	if err := f.SetCellValue(sheet, "A1", "Prometheus 巡检报告"); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "A1", "A1", x.titleStyle); err != nil {
+		return err
+	}
+	if err := f.SetSheetRow(sheet, "A2", &[]interface{}{"生成时间", excelTime(x.data.Timestamp)}); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, "B2", "B2", x.timeStyle); err != nil {
+		return err
+	}
+
+	header := []interface{}{"指标类型", "总数", "严重", "警告", "无数据", "查询失败"}
+	if err := x.writeHeader(sheet, 4, header); err != nil {
+		return err
+	}
+	row := 5
+	for i, groupType := range types {
+		stats := x.data.MetricGroups[groupType].Stats
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		values := []interface{}{groupType, stats.TotalCount, stats.CriticalCount, stats.WarningCount, stats.NoDataCount, stats.ErrorCount}
+		if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+			return err
+		}
+		// 指标类型链接到对应的工作表
+		if err := f.SetCellHyperLink(sheet, cell, fmt.Sprintf("'%s'!A1", sheets[i]), "Location"); err != nil {
+			return err
+		}
+		row++
+	}
+	if len(types) > 0 {
+		for col, status := range map[string]string{"C": "critical", "D": "warning"} {
+			rangeRef := fmt.Sprintf("%s5:%s%d", col, col, row-1)
+			format := x.statusStyle[status]
+			if err := f.SetConditionalFormat(sheet, rangeRef, []excelize.ConditionalFormatOptions{
+				{Type: "cell", Criteria: ">", Value: "0", Format: &format},
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(x.data.TimedOutMetrics) > 0 {
+		row++
+		if err := x.writeHeader(sheet, row, []interface{}{"查询超时的指标", "指标名称", "数据源", "超时时间", "PromQL"}); err != nil {
+			return err
+		}
+		for _, metric := range x.data.TimedOutMetrics {
+			row++
+			cell, _ := excelize.CoordinatesToCellName(1, row)
+			if err := f.SetSheetRow(sheet, cell, &[]interface{}{metric.Type, metric.Name, metric.Datasource, metric.Timeout.String(), metric.Query}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := f.SetColWidth(sheet, "A", "A", 28); err != nil {
+		return err
+	}
+	return f.SetColWidth(sheet, "B", "F", 20)
+}
+
+// groupSheet 生成指标类型的工作表，标签列使用配置的别名，按状态列设置条件格式
+func (x *xlsxReport) groupSheet(sheet string, group *MetricGroup) error {
+	f := x.file
+	showDatasource := x.data.ShowDatasource()
+	aliases := labelAliases(group)
+
+	header := []interface{}{"指标名称"}
+	if showDatasource {
+		header = append(header, x.data.DatasourceTitle())
+	}
+	for _, alias := range aliases {
+		header = append(header, alias)
+	}
+	valueCol := len(header) + 1
+	header = append(header, "当前值", "单位", "阈值", "状态", "检查时间")
+	statusCol, timeCol := valueCol+3, valueCol+4
+	if err := x.writeHeader(sheet, 1, header); err != nil {
+		return err
+	}
+
+	row := 1
+	for _, name := range sortedMetricNames(group) {
+		for _, metric := range group.MetricsByName[name] {
+			row++
+			values := []interface{}{metric.Name}
+			if showDatasource {
+				values = append(values, metric.Datasource)
+			}
+			for _, alias := range aliases {
+				values = append(values, labelByAlias(metric, alias))
+			}
+			var value interface{}
+			if metric.HasValue() {
+				value = metric.Value
+			}
+			values = append(values, value, metric.Unit, thresholdText(metric), metric.StatusText, excelTime(metric.Timestamp))
+			cell, _ := excelize.CoordinatesToCellName(1, row)
+			if err := f.SetSheetRow(sheet, cell, &values); err != nil {
+				return err
+			}
+		}
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(timeCol)
+	valueName, _ := excelize.ColumnNumberToName(valueCol)
+	statusName, _ := excelize.ColumnNumberToName(statusCol)
+	if err := f.SetColWidth(sheet, "A", lastCol, 16); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheet, "A", "A", 28); err != nil {
+		return err
+	}
+	if err := f.SetColWidth(sheet, lastCol, lastCol, 20); err != nil {
+		return err
+	}
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, YSplit: 1, TopLeftCell: "A2", ActivePane: "bottomLeft"}); err != nil {
+		return err
+	}
+	if row == 1 {
+		return nil
+	}
+
+	if err := f.SetCellStyle(sheet, fmt.Sprintf("%s2", valueName), fmt.Sprintf("%s%d", valueName, row), x.numberStyle); err != nil {
+		return err
+	}
+	if err := f.SetCellStyle(sheet, fmt.Sprintf("%s2", lastCol), fmt.Sprintf("%s%d", lastCol, row), x.timeStyle); err != nil {
+		return err
+	}
+	if err := f.AutoFilter(sheet, fmt.Sprintf("A1:%s%d", lastCol, row), nil); err != nil {
+		return err
+	}
+
+	// 按状态列为整行着色，用户筛选或修改数据后颜色仍然正确
+	var formats []excelize.ConditionalFormatOptions
+	for _, status := range xlsxStatuses {
+		style := x.statusStyle[status]
+		formats = append(formats, excelize.ConditionalFormatOptions{
+			Type:     "formula",
+			Criteria: fmt.Sprintf(`$%s2="%s"`, statusName, GetStatusText(status)),
+			Format:   &style,
+		})
+	}
+	return f.SetConditionalFormat(sheet, fmt.Sprintf("A2:%s%d", lastCol, row), formats)
+}
+
+// writeHeader 在指定行写入带样式的表头
+func (x *xlsxReport) writeHeader(sheet string, row int, header []interface{}) error {
+	first, _ := excelize.CoordinatesToCellName(1, row)
+	last, _ := excelize.CoordinatesToCellName(len(header), row)
+	if err := x.file.SetSheetRow(sheet, first, &header); err != nil {
+		return err
+	}
+	return x.file.SetCellStyle(sheet, first, last, x.headerStyle)
+}
+
+// excelTime 保留本地时间的年月日时分秒，excelize 按 UTC 计算 Excel 日期
+func excelTime(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC)
+}
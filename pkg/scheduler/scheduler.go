@@ -0,0 +1,207 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"sync"
+	"time"
+
+	"PromAI/pkg/config"
+
+	"github.com/robfig/cron/v3"
+)
+
+// HistorySize 每个定时任务保留的执行记录数量
+const HistorySize = 20
+
+// 执行结果
+const (
+	RunSuccess = "success"
+	RunFailed  = "failed"
+	RunSkipped = "skipped" // 上一次执行尚未结束，本次跳过
+)
+
+// RunFunc 定时任务执行的巡检函数，format 为报告格式，返回生成的报告路径
+type RunFunc func(ctx context.Context, format string) (string, error)
+
+// RunRecord 一次执行的记录
+type RunRecord struct {
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration_ns"`
+	Result   string        `json:"result"`
+	Report   string        `json:"report,omitempty"` // 生成的报告路径
+	Error    string        `json:"error,omitempty"`
+}
+
+// ScheduleStatus 定时任务的当前状态
+type ScheduleStatus struct {
+	Name    string        `json:"name"`
+	Cron    string        `json:"cron"`
+	Format  string        `json:"format"`
+	Jitter  time.Duration `json:"jitter_ns"`
+	Running bool          `json:"running"`
+	LastRun *RunRecord    `json:"last_run"`
+	NextRun time.Time     `json:"next_run"`
+	History []RunRecord   `json:"history"` // 最近的执行记录，从新到旧
+}
+
+// entry 一个定时任务
+type entry struct {
+	schedule config.Schedule
+	id       cron.EntryID
+
+	mu      sync.Mutex
+	running bool
+	history []RunRecord
+}
+
+// Scheduler 按 cron 表达式定时执行巡检
+type Scheduler struct {
+	cron    *cron.Cron
+	run     RunFunc
+	entries []*entry
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New 创建调度器并校验所有定时任务的配置，supported 用于校验报告格式
+func New(schedules []config.Schedule, run RunFunc, supported func(format string) bool) (*Scheduler, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Scheduler{
+		cron:   cron.New(),
+		run:    run,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	names := make(map[string]bool, len(schedules))
+	for _, schedule := range schedules {
+		if schedule.Name == "" {
+			cancel()
+			return nil, fmt.Errorf("schedule requires a name: %q", schedule.Cron)
+		}
+		if names[schedule.Name] {
+			cancel()
+			return nil, fmt.Errorf("duplicate schedule name: %s", schedule.Name)
+		}
+		names[schedule.Name] = true
+		if !supported(schedule.Format) {
+			cancel()
+			return nil, fmt.Errorf("unsupported report format of schedule %s: %q", schedule.Name, schedule.Format)
+		}
+
+		e := &entry{schedule: schedule}
+		id, err := s.cron.AddFunc(schedule.Cron, func() { s.execute(e) })
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("parsing cron of schedule %s: %w", schedule.Name, err)
+		}
+		e.id = id
+		s.entries = append(s.entries, e)
+	}
+	return s, nil
+}
+
+// Start 启动调度器
+func (s *Scheduler) Start() {
+	s.cron.Start()
+	for _, e := range s.entries {
+		log.Printf("定时任务 [%s] 已启动, cron: %s, 下次执行时间: %s",
+			e.schedule.Name, e.schedule.Cron, s.cron.Entry(e.id).Next.Format("2006-01-02 15:04:05"))
+	}
+}
+
+// Stop 停止调度器，取消正在执行的任务并等待其退出
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.cron.Stop().Done()
+}
+
+// Status 返回所有定时任务的状态
+func (s *Scheduler) Status() []ScheduleStatus {
+	statuses := make([]ScheduleStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		status := ScheduleStatus{
+			Name:    e.schedule.Name,
+			Cron:    e.schedule.Cron,
+			Format:  e.schedule.Format,
+			Jitter:  e.schedule.Jitter,
+			NextRun: s.cron.Entry(e.id).Next,
+		}
+
+		e.mu.Lock()
+		status.Running = e.running
+		status.History = make([]RunRecord, 0, len(e.history))
+		for i := len(e.history) - 1; i >= 0; i-- {
+			status.History = append(status.History, e.history[i])
+		}
+		e.mu.Unlock()
+
+		if len(status.History) > 0 {
+			status.LastRun = &status.History[0]
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// execute 执行一次定时任务，上一次执行尚未结束时跳过
+func (s *Scheduler) execute(e *entry) {
+	name := e.schedule.Name
+	start := time.Now()
+
+	e.mu.Lock()
+	if e.running {
+		e.mu.Unlock()
+		log.Printf("定时任务 [%s] 上一次执行尚未结束，跳过本次执行", name)
+		e.record(RunRecord{Start: start, End: start, Result: RunSkipped})
+		return
+	}
+	e.running = true
+	e.mu.Unlock()
+
+	defer func() {
+		e.mu.Lock()
+		e.running = false
+		e.mu.Unlock()
+	}()
+
+	// 随机延迟，避免多个实例同时查询 Prometheus
+	if e.schedule.Jitter > 0 {
+		delay := time.Duration(rand.Int63n(int64(e.schedule.Jitter)))
+		log.Printf("定时任务 [%s] 随机延迟 %v 后执行", name, delay)
+		select {
+		case <-time.After(delay):
+		case <-s.ctx.Done():
+			return
+		}
+	}
+
+	log.Printf("定时任务 [%s] 开始执行", name)
+	record := RunRecord{Start: time.Now()}
+	reportPath, err := s.run(s.ctx, e.schedule.Format)
+	record.End = time.Now()
+	record.Duration = record.End.Sub(record.Start)
+	if err != nil {
+		record.Result, record.Error = RunFailed, err.Error()
+		log.Printf("定时任务 [%s] 执行失败, 耗时 %v: %v", name, record.Duration, err)
+	} else {
+		record.Result, record.Report = RunSuccess, reportPath
+		log.Printf("定时任务 [%s] 执行成功, 耗时 %v, 报告: %s", name, record.Duration, reportPath)
+	}
+	e.record(record)
+}
+
+// record 保存执行记录，只保留最近 HistorySize 条
+func (e *entry) record(record RunRecord) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.history = append(e.history, record)
+	if len(e.history) > HistorySize {
+		e.history = e.history[len(e.history)-HistorySize:]
+	}
+}
@@ -0,0 +1,142 @@
+package status
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v2"
+
+	"PromAI/pkg/config"
+)
+
+// cacheDateFormat 缓存键中使用的日期格式
+const cacheDateFormat = "2006-01-02"
+
+// Cache 缓存已经结束的日期的指标状态
+// 过去某一天的状态不会再变化，因此只需查询一次；当天的状态不会被缓存
+type Cache struct {
+	mu      sync.RWMutex
+	saveMu  sync.Mutex // 串行写入缓存文件
+	path    string
+	entries map[string]string
+}
+
+// NewCache 创建状态缓存，path 不为空时从文件加载并在 Save 时写回
+func NewCache(path string) (*Cache, error) {
+	c := &Cache{
+		path:    path,
+		entries: make(map[string]string),
+	}
+	if path == "" {
+		return c, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading status cache: %w", err)
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		// 缓存文件损坏时丢弃缓存，历史状态会重新查询
+		log.Printf("解析状态缓存失败，使用空缓存: %s: %v", path, err)
+		c.entries = make(map[string]string)
+		return c, nil
+	}
+	log.Printf("已加载 %d 条历史状态缓存: %s", len(c.entries), path)
+	return c, nil
+}
+
+// Get 获取指标在某一天的缓存状态
+func (c *Cache) Get(metric config.MetricConfig, day time.Time) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	status, ok := c.entries[cacheKey(metric, day)]
+	return status, ok
+}
+
+// Set 缓存指标在某一天的状态
+func (c *Cache) Set(metric config.MetricConfig, day time.Time, status string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[cacheKey(metric, day)] = status
+}
+
+// Prune 删除 before 之前的日期的缓存
+func (c *Cache) Prune(before time.Time) {
+	if c == nil {
+		return
+	}
+	cutoff := before.Format(cacheDateFormat)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if len(key) >= len(cacheDateFormat) && key[:len(cacheDateFormat)] < cutoff {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// Save 将缓存写入文件，未配置文件路径时不做任何事
+func (c *Cache) Save() error {
+	if c == nil || c.path == "" {
+		return nil
+	}
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	c.mu.RLock()
+	data, err := json.Marshal(c.entries)
+	c.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("encoding status cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("creating status cache dir: %w", err)
+	}
+	// 先写入同目录下的临时文件再替换，写入中途失败不会损坏原有缓存
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("creating status cache temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("writing status cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("writing status cache: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("writing status cache: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("replacing status cache: %w", err)
+	}
+	return nil
+}
+
+// cacheKey 由日期和指标配置生成缓存键，指标的查询或阈值变化后缓存自动失效
+func cacheKey(metric config.MetricConfig, day time.Time) string {
+	raw, err := yaml.Marshal(metric)
+	if err != nil {
+		raw = []byte(metric.Name + metric.Query)
+	}
+	sum := sha1.Sum(raw)
+	return day.Format(cacheDateFormat) + ":" + hex.EncodeToString(sum[:])
+}
@@ -3,6 +3,7 @@ package status
 import (
 	"context"
 	"log"
+	"sync"
 	"time"
 
 	"PromAI/pkg/config"
@@ -34,25 +35,28 @@ type MetricConfig struct {
 }
 
 type StatusSummary struct {
-	Normal       int
-	Warning      int // 新增警告状态计数
-	Abnormal     int
-	TotalMetrics int            // 总指标数
-	TypeCounts   map[string]int // 每种类型的指标数量
+	Normal       int            `json:"normal"`
+	Warning      int            `json:"warning"` // 新增警告状态计数
+	Abnormal     int            `json:"abnormal"`
+	TotalMetrics int            `json:"total_metrics"` // 总指标数
+	TypeCounts   map[string]int `json:"type_counts"`   // 每种类型的指标数量
 }
 
 type MetricStatus struct {
-	Name          string
-	DailyStatus   map[string]string // key是日期，value是状态("normal"/"warning"/"abnormal")
-	Threshold     float64
-	Unit          string
-	ThresholdType string
+	Name          string            `json:"name"`
+	Datasource    string            `json:"datasource"`
+	DailyStatus   map[string]string `json:"daily_status"` // key是日期，value是状态("normal"/"warning"/"abnormal")
+	Threshold     float64           `json:"threshold"`
+	ThresholdDesc string            `json:"threshold_desc,omitempty"` // 区间和分段阈值的描述
+	Unit          string            `json:"unit"`
+	ThresholdType string            `json:"threshold_type"`
 }
 
 type StatusData struct {
-	Summary StatusSummary
-	Metrics []MetricStatus
-	Dates   []string
+	Summary     StatusSummary  `json:"summary"`
+	Metrics     []MetricStatus `json:"metrics"`
+	Dates       []string       `json:"dates"`
+	Datasources []string       `json:"datasources"` // 看板涉及的数据源名称
 }
 
 func GenerateStatusData(days int) (*StatusData, error) {
@@ -74,8 +78,23 @@ func GenerateStatusData(days int) (*StatusData, error) {
 	return data, nil
 }
 
-func CollectMetricStatus(client metrics.PrometheusAPI, config *config.Config) (*StatusData, error) {
-	data, err := GenerateStatusData(7) // 显示最近7天的数据
+// statusJob 某个指标在某一天的状态查询任务
+type statusJob struct {
+	metric int // data.Metrics 中的下标
+	day    time.Time
+	date   string
+	status string
+	err    error
+	cached bool
+}
+
+// CollectMetricStatus 收集最近若干天的指标状态
+// 每个指标每天的查询并发执行，已经结束的日期的状态从 cache 中读取，只有当天的状态每次重新查询
+func CollectMetricStatus(ctx context.Context, collector *metrics.Collector, cfg *config.Config, cache *Cache) (*StatusData, error) {
+	days, concurrency := cfg.Status.Limits()
+	_, queryTimeout, _ := cfg.Collect.Limits()
+
+	data, err := GenerateStatusData(days)
 	if err != nil {
 		log.Printf("生成状态数据失败: %v", err)
 		return nil, err
@@ -83,8 +102,14 @@ func CollectMetricStatus(client metrics.PrometheusAPI, config *config.Config) (*
 
 	log.Printf("开始收集指标状态数据，时间范围: %v", data.Dates)
 
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+
+	var metricConfigs []config.MetricConfig
+	var jobs []*statusJob
+
 	// 遍历所有指标类型
-	for _, metricType := range config.MetricTypes {
+	for _, metricType := range cfg.MetricTypes {
 		log.Printf("处理指标类型: %s", metricType.Type)
 
 		// 统计每种类型的指标数量
@@ -97,43 +122,113 @@ func CollectMetricStatus(client metrics.PrometheusAPI, config *config.Config) (*
 			log.Printf("处理指标: %s (阈值: %v %s, 阈值类型: %s)",
 				metric.Name, metric.Threshold, metric.Unit, metric.ThresholdType)
 
-			metricStatus := MetricStatus{
+			// 记录实际使用的数据源，同时使缓存键区分不同数据源
+			metric.Datasource = collector.ResolveDatasource(metricType.DatasourceFor(metric))
+
+			data.Metrics = append(data.Metrics, MetricStatus{
 				Name:          metric.Name,
+				Datasource:    metric.Datasource,
 				DailyStatus:   make(map[string]string),
-				Threshold:     metric.Threshold,
+				Threshold:     metrics.DisplayThreshold(metric),
+				ThresholdDesc: metrics.DescribeThreshold(metric),
 				Unit:          metric.Unit,
 				ThresholdType: metric.ThresholdType,
+			})
+			metricConfigs = append(metricConfigs, metric)
+
+			for i, date := range data.Dates {
+				job := &statusJob{
+					metric: len(data.Metrics) - 1,
+					day:    today.AddDate(0, 0, i-(days-1)),
+					date:   date,
+				}
+				if job.day.Before(today) {
+					job.status, job.cached = cache.Get(metric, job.day)
+				}
+				jobs = append(jobs, job)
 			}
+		}
+	}
 
-			// 查询每天的状态
-			for _, date := range data.Dates {
-				status, err := queryMetricStatus(client, metric, date)
-				if err != nil {
-					log.Printf("查询指标 [%s] 在 %s 的状态失败: %v", metric.Name, date, err)
-					metricStatus.DailyStatus[date] = "abnormal"
-					data.Summary.Abnormal++
-				} else {
-					metricStatus.DailyStatus[date] = status
-					switch status {
-					case "normal":
-						log.Printf("指标 [%s] 在 %s 状态正常", metric.Name, date)
-						data.Summary.Normal++
-					case "warning":
-						log.Printf("指标 [%s] 在 %s 状态警告", metric.Name, date)
-						data.Summary.Warning++
-					case "abnormal":
-						log.Printf("指标 [%s] 在 %s 状态异常", metric.Name, date)
-						data.Summary.Abnormal++
-					}
-				}
+	// 并发查询未命中缓存的状态
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, job := range jobs {
+		if job.cached {
+			continue
+		}
+		wg.Add(1)
+		go func(job *statusJob) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				job.status, job.err = "abnormal", ctx.Err()
+				return
+			}
+
+			client, err := collector.ClientFor(metricConfigs[job.metric].Datasource)
+			if err != nil {
+				job.status, job.err = "abnormal", err
+				return
+			}
+
+			queryCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+			defer cancel()
+			var hasData bool
+			job.status, hasData, job.err = queryMetricStatus(queryCtx, client, metricConfigs[job.metric], job.day)
+			// 只缓存有采样数据的已结束日期，数据可能稍后才写入 Prometheus
+			if job.err == nil && hasData && job.day.Before(today) {
+				cache.Set(metricConfigs[job.metric], job.day, job.status)
 			}
+		}(job)
+	}
+	wg.Wait()
+
+	// 汇总每天的状态
+	cachedCount := 0
+	for _, job := range jobs {
+		metricStatus := &data.Metrics[job.metric]
+		if job.cached {
+			cachedCount++
+		}
+		if job.err != nil {
+			log.Printf("查询指标 [%s] 在 %s 的状态失败: %v", metricStatus.Name, job.date, job.err)
+			metricStatus.DailyStatus[job.date] = "abnormal"
+			data.Summary.Abnormal++
+			continue
+		}
+
+		metricStatus.DailyStatus[job.date] = job.status
+		switch job.status {
+		case "normal":
+			log.Printf("指标 [%s] 在 %s 状态正常", metricStatus.Name, job.date)
+			data.Summary.Normal++
+		case "warning":
+			log.Printf("指标 [%s] 在 %s 状态警告", metricStatus.Name, job.date)
+			data.Summary.Warning++
+		case "abnormal":
+			log.Printf("指标 [%s] 在 %s 状态异常", metricStatus.Name, job.date)
+			data.Summary.Abnormal++
+		}
+	}
 
-			data.Metrics = append(data.Metrics, metricStatus)
+	seen := make(map[string]bool)
+	for _, metric := range data.Metrics {
+		if !seen[metric.Datasource] {
+			seen[metric.Datasource] = true
+			data.Datasources = append(data.Datasources, metric.Datasource)
 		}
 	}
 
-	log.Printf("状态数据收集完成. 总指标数: %d, 正常: %d, 警告: %d, 异常: %d",
-		data.Summary.TotalMetrics, data.Summary.Normal, data.Summary.Warning, data.Summary.Abnormal)
+	cache.Prune(today.AddDate(0, 0, -(days - 1)))
+	if err := cache.Save(); err != nil {
+		log.Printf("保存状态缓存失败: %v", err)
+	}
+
+	log.Printf("状态数据收集完成. 总指标数: %d, 正常: %d, 警告: %d, 异常: %d, 命中缓存: %d/%d",
+		data.Summary.TotalMetrics, data.Summary.Normal, data.Summary.Warning, data.Summary.Abnormal, cachedCount, len(jobs))
 
 	// 打印每种类型的指标数量
 	for typeName, count := range data.Summary.TypeCounts {
@@ -143,16 +238,10 @@ func CollectMetricStatus(client metrics.PrometheusAPI, config *config.Config) (*
 	return data, nil
 }
 
-func queryMetricStatus(client metrics.PrometheusAPI, metric config.MetricConfig, date string) (string, error) {
-	ctx := context.Background()
-
-	dateTime, err := time.Parse("01-02", date)
-	if err != nil {
-		return "abnormal", err
-	}
-
+// queryMetricStatus 查询指标在某一天最严重的状态，第二个返回值表示查询结果中是否有采样点
+func queryMetricStatus(ctx context.Context, client metrics.PrometheusAPI, metric config.MetricConfig, day time.Time) (string, bool, error) {
 	// 设置查询时间范围为那一天的0点到23:59:59
-	startTime := time.Date(time.Now().Year(), dateTime.Month(), dateTime.Day(), 0, 0, 0, 0, time.Local)
+	startTime := day
 	endTime := startTime.Add(24 * time.Hour).Add(-time.Second)
 
 	log.Printf(`
@@ -181,26 +270,31 @@ PromQL: %s
 
 	if err != nil {
 		log.Printf("执行查询失败 [%s]: %v", metric.Query, err)
-		return "abnormal", err
+		return "abnormal", false, err
 	}
 
 	switch v := result.(type) {
 	case model.Matrix:
 		if len(v) == 0 {
 			log.Printf("指标 [%s] 查询结果为空", metric.Name)
-			return "abnormal", nil
+			return "abnormal", false, nil
 		}
 
 		log.Printf("指标 [%s] 返回 %d 个时间序列", metric.Name, len(v))
 
-		maxValue := float64(0)
+		// 逐个采样点判断状态，取最严重的状态及对应的值
+		status, worstValue := "", float64(0)
 		// 遍历每个时间序列
 		for _, series := range v {
-			// 遍历每个采样点，找出最大值
+			labels := make(map[string]string, len(series.Metric))
+			for name, value := range series.Metric {
+				labels[string(name)] = string(value)
+			}
 			for _, sample := range series.Values {
 				value := float64(sample.Value)
-				if value > maxValue {
-					maxValue = value
+				sampleStatus := checkStatus(metric, value, labels, sample.Timestamp.Time())
+				if status == "" || statusSeverity(sampleStatus) > statusSeverity(status) {
+					status, worstValue = sampleStatus, value
 				}
 				log.Printf("指标 [%s] 时间: %v, 值: %v",
 					metric.Name,
@@ -208,87 +302,43 @@ PromQL: %s
 					value)
 			}
 		}
+		if status == "" {
+			log.Printf("指标 [%s] 查询结果没有采样点", metric.Name)
+			return "abnormal", false, nil
+		}
 
-		// 使用最大值进行阈值判断
-		status := checkThreshold(maxValue, metric.Threshold, metric.ThresholdType)
-		log.Printf("指标 [%s] 最大值: %v, 阈值: %v, 阈值类型: %s, 状态: %s",
+		log.Printf("指标 [%s] 最严重的值: %v, 阈值: %v, 阈值类型: %s, 状态: %s",
 			metric.Name,
-			maxValue,
-			metric.Threshold,
+			worstValue,
+			metrics.DisplayThreshold(metric),
 			metric.ThresholdType,
 			status)
 
-		return status, nil
+		return status, true, nil
 
 	default:
 		log.Printf("指标 [%s] 返回了意外的结果类型: %T", metric.Name, result)
-		return "abnormal", nil
+		return "abnormal", false, nil
 	}
 }
 
-// 根据阈值类型判断状态
-func checkThreshold(value, threshold float64, thresholdType string) string {
-	if thresholdType == "" {
-		thresholdType = "greater" // 默认值
+// checkStatus 使用与报告相同的状态规则和阈值逻辑判断状态，并转换为看板的状态名称
+func checkStatus(metric config.MetricConfig, value float64, labels map[string]string, ts time.Time) string {
+	status := metrics.EvaluateStatus(metric, value, labels, ts)
+	if status == metrics.StatusCritical {
+		return "abnormal"
 	}
+	return status
+}
 
-	// 警告阈值为正常阈值的90%
-	warningFactor := 0.9
-
-	switch thresholdType {
-	case "greater":
-		// 当值大于阈值时告警
-		// 例如：CPU使用率 > 80% 告警
-		if value > threshold {
-			return "abnormal"
-		} else if value > threshold*warningFactor {
-			return "warning"
-		}
-		return "normal"
-	case "greater_equal":
-		// 当值大于等于阈值时告警
-		if value >= threshold {
-			return "abnormal"
-		} else if value >= threshold*warningFactor {
-			return "warning"
-		}
-		return "normal"
-	case "less":
-		// 当值小于阈值时告警
-		// 例如：可用节点数 < 3 告警
-		if value < threshold {
-			return "abnormal"
-		} else if value < threshold/warningFactor {
-			return "warning"
-		}
-		return "normal"
-	case "less_equal":
-		// 当值小于等于阈值时告警
-		if value <= threshold {
-			return "abnormal"
-		} else if value <= threshold/warningFactor {
-			return "warning"
-		}
-		return "normal"
-	case "equal":
-		// 值必须等于阈值才正常
-		if value == threshold {
-			return "normal"
-		}
-		return "abnormal"
-	case "not_equal":
-		// 值不等于阈值才正常
-		if value != threshold {
-			return "normal"
-		}
-		return "abnormal"
+// statusSeverity 返回看板状态的严重程度，用于取最严重的状态
+func statusSeverity(status string) int {
+	switch status {
+	case "normal":
+		return 0
+	case "warning":
+		return 1
 	default:
-		// 默认情况：大于阈值告警
-		if value > threshold {
-			return "abnormal"
-		} else if value > threshold*warningFactor {
-			return "warning"
-		}
-		return "normal"
+		return 2
 	}
 }
@@ -0,0 +1,68 @@
+// Package templates 内置的报告、看板和邮件模板，以及离线查看报告所需的静态资源
+package templates
+
+import (
+	"embed"
+	"errors"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// 更新 Chart.js 时修改版本号后执行 go generate ./templates，下载的是保留许可证注释的压缩版本
+//go:generate curl -sSfL -o static/chart.umd.min.js https://cdn.jsdelivr.net/npm/chart.js@4.4.7/dist/chart.umd.min.js
+
+//go:embed *.html static
+var builtin embed.FS
+
+// chartJSFile 内联到 HTML 报告中的 Chart.js
+const chartJSFile = "static/chart.umd.min.js"
+
+// Dir 覆盖内置模板的目录，其中存在的同名文件优先于内置文件，为空时只使用内置模板
+var Dir string
+
+// overlayFS 先在覆盖目录中查找文件，不存在时使用内置文件
+type overlayFS struct {
+	dir  fs.FS
+	base fs.FS
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.dir.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return o.base.Open(name)
+	}
+	return file, err
+}
+
+// FS 返回模板文件系统，配置了 Dir 时其中的文件覆盖内置文件
+func FS() fs.FS {
+	if Dir == "" {
+		return builtin
+	}
+	return overlayFS{dir: os.DirFS(Dir), base: builtin}
+}
+
+// ReadFile 读取模板文件，绝对路径直接从磁盘读取，相对路径先在 Dir 中查找，再使用内置文件
+func ReadFile(name string) ([]byte, error) {
+	if filepath.IsAbs(name) {
+		return os.ReadFile(name)
+	}
+	return fs.ReadFile(FS(), path.Clean(filepath.ToSlash(name)))
+}
+
+// Parse 解析模板文件，t 的名称应与第一个文件名一致
+func Parse(t *template.Template, names ...string) (*template.Template, error) {
+	return t.ParseFS(FS(), names...)
+}
+
+// ChartJS 返回内联到报告中的 Chart.js，没有内置或覆盖的文件时为空，报告改为从 CDN 加载
+func ChartJS() template.JS {
+	content, err := fs.ReadFile(FS(), chartJSFile)
+	if err != nil {
+		return ""
+	}
+	return template.JS(content)
+}